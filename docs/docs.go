@@ -15,9 +15,54 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys for verifying JWT access tokens (RFC 7517). Empty unless token.format is jwt.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Well-Known"
+                ],
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "Published JSON Web Keys",
+                        "schema": {
+                            "$ref": "#/definitions/model.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
+        "/.well-known/paseto-keys": {
+            "get": {
+                "description": "Public keys that downstream services use to verify v2.public / v4.public tokens. Empty when tokens use a shared local key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Well-Known"
+                ],
+                "summary": "PASETO Public Keys",
+                "responses": {
+                    "200": {
+                        "description": "Published verification keys",
+                        "schema": {
+                            "$ref": "#/definitions/model.PasetoKeySet"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/confirm": {
             "post": {
-                "description": "API to authenticate user and generate paseto token",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turns on two-factor login after checking a first code from the enrolled authenticator app",
                 "consumes": [
                     "application/json"
                 ],
@@ -27,21 +72,21 @@ const docTemplate = `{
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Login",
+                "summary": "Confirm Two-Factor Authentication",
                 "parameters": [
                     {
-                        "description": "Authentication request",
-                        "name": "auth",
+                        "description": "Code from the authenticator app",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.AuthReq"
+                            "$ref": "#/definitions/model.TwoFactorCodeReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Authentication response with paseto token",
+                        "description": "Two-factor authentication enabled",
                         "schema": {
                             "allOf": [
                                 {
@@ -51,7 +96,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.TokenOutput"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -59,7 +104,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request or invalid code",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
@@ -70,6 +115,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden while impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -79,14 +130,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/logout": {
+        "/auth/2fa/enroll": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "API to logout user and invalidate all user sessions",
+                "description": "Creates a TOTP secret for the current user and returns it as an otpauth URI and QR code PNG, with ten single-use recovery codes. It is not required at login until confirmed with /auth/2fa/confirm.",
                 "consumes": [
                     "application/json"
                 ],
@@ -96,10 +147,10 @@ const docTemplate = `{
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Logout",
+                "summary": "Enroll Two-Factor Authentication",
                 "responses": {
                     "200": {
-                        "description": "Logout successful",
+                        "description": "Secret to add to an authenticator app",
                         "schema": {
                             "allOf": [
                                 {
@@ -109,19 +160,31 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "string"
+                                            "$ref": "#/definitions/model.TwoFactorEnrollment"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden while impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -131,9 +194,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/refresh": {
-            "post": {
-                "description": "API to refresh paseto token",
+        "/auth/2fa/recovery-codes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shows how many unused two-factor recovery codes the current user has left",
                 "consumes": [
                     "application/json"
                 ],
@@ -143,21 +211,10 @@ const docTemplate = `{
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Refresh Token",
-                "parameters": [
-                    {
-                        "description": "Refresh token request",
-                        "name": "refresh",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.RefreshTokenReq"
-                        }
-                    }
-                ],
+                "summary": "Recovery Codes Status",
                 "responses": {
                     "200": {
-                        "description": "New access token",
+                        "description": "Remaining recovery codes",
                         "schema": {
                             "allOf": [
                                 {
@@ -167,7 +224,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.TokenOutput"
+                                            "$ref": "#/definitions/model.RecoveryCodesOutput"
                                         }
                                     }
                                 }
@@ -175,13 +232,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Two-factor authentication not enabled",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
                     "401": {
-                        "description": "Invalid refresh token",
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
@@ -193,16 +250,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "API to get paginated list of users with optional search",
+                "description": "Replaces all two-factor recovery codes with ten new ones after checking a current TOTP or recovery code. The new codes are only shown in this response.",
                 "consumes": [
                     "application/json"
                 ],
@@ -210,47 +265,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "List User",
+                "summary": "Regenerate Recovery Codes",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number (default: 1)",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Page size (default: 10, max: 100)",
-                        "name": "size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search by name, email, role, or department",
-                        "name": "search",
-                        "in": "query"
+                        "description": "Current TOTP or recovery code",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorCodeReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of users with pagination",
+                        "description": "New recovery codes",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/model.JsonResponseTotal"
+                                    "$ref": "#/definitions/model.JsonResponse"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.User"
-                                            }
+                                            "$ref": "#/definitions/model.RecoveryCodesOutput"
                                         }
                                     }
                                 }
@@ -258,33 +299,41 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid query parameters",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized - Invalid or missing token",
+                        "description": "Unauthorized or invalid code",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
                     "403": {
-                        "description": "Forbidden - Insufficient permissions",
+                        "description": "Forbidden while impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
                     "500": {
-                        "description": "Internal server error - Database or system error",
+                        "description": "Internal error",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/2fa/verify": {
             "post": {
-                "description": "API to create a new user",
+                "description": "Second login step for users with two-factor authentication: trades the mfa_token from /auth/login and a code from the authenticator app, or an unused recovery code, for a token pair",
                 "consumes": [
                     "application/json"
                 ],
@@ -292,23 +341,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "Create User",
+                "summary": "Verify Two-Factor Code",
                 "parameters": [
                     {
-                        "description": "Authentication request",
-                        "name": "auth",
+                        "description": "MFA token and code",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.CreateUserRequest"
+                            "$ref": "#/definitions/model.TwoFactorVerifyReq"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT; binds the issued tokens to its key",
+                        "name": "DPoP",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User registered successfully",
+                        "description": "Authentication response with paseto token",
                         "schema": {
                             "allOf": [
                                 {
@@ -318,7 +373,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/model.TokenOutput"
                                         }
                                     }
                                 }
@@ -332,7 +387,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid token or code",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
@@ -346,14 +407,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get detailed user information by ID",
+        "/auth/email-otp": {
+            "post": {
+                "description": "Emails a six digit sign-in code to the account with this email. The response is the same whether or not the account exists.",
                 "consumes": [
                     "application/json"
                 ],
@@ -361,22 +417,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "Get User by ID",
+                "summary": "Request Email Sign-in Code",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Account email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordlessReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User details retrieved successfully",
+                        "description": "Request accepted",
                         "schema": {
                             "allOf": [
                                 {
@@ -386,7 +443,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -394,32 +451,23 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID format",
-                        "schema": {
-                            "$ref": "#/definitions/model.JsonResponsError"
-                        }
-                    },
-                    "404": {
-                        "description": "User not found",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal error",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Update existing user information",
+            }
+        },
+        "/auth/email-otp/verify": {
+            "post": {
+                "description": "Trades the newest emailed code for a token pair, or for an mfa_token when the user has two-factor authentication. A code stops working after five wrong guesses. Using the code also verifies the email address. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.",
                 "consumes": [
                     "application/json"
                 ],
@@ -427,31 +475,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "Update User",
+                "summary": "Verify Email Sign-in Code",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "User update data",
+                        "description": "Email and code",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.UpdateUserRequest"
+                            "$ref": "#/definitions/model.EmailOTPVerifyReq"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT; binds the issued tokens to its key",
+                        "name": "DPoP",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User updated successfully",
+                    "200": {
+                        "description": "Authentication response with paseto token",
                         "schema": {
                             "allOf": [
                                 {
@@ -461,7 +507,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/model.TokenOutput"
                                         }
                                     }
                                 }
@@ -469,32 +515,34 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid input data",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "401": {
+                        "description": "Invalid or expired",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal error",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/auth/impersonate/{id}": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Soft delete user (mark as deleted)",
+                "description": "API for administrators to act as another user. Returns a short-lived access token without a refresh token; its act claim names the administrator and every request made with it is audited.",
                 "consumes": [
                     "application/json"
                 ],
@@ -502,14 +550,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "Delete User",
+                "summary": "Impersonate User",
                 "parameters": [
                     {
                         "minimum": 1,
                         "type": "integer",
-                        "description": "User ID to delete",
+                        "description": "User ID to impersonate",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -517,7 +565,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "User deleted successfully",
+                        "description": "Impersonation access token",
                         "schema": {
                             "allOf": [
                                 {
@@ -527,7 +575,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "string"
+                                            "$ref": "#/definitions/model.TokenOutput"
                                         }
                                     }
                                 }
@@ -535,55 +583,1996 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID format",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Forbidden - Not an administrator or already impersonating",
                         "schema": {
                             "$ref": "#/definitions/model.JsonResponsError"
                         }
-                    }
-                }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "API to authenticate user and generate paseto token. Accounts with an unverified email are refused unless registration.unverifiedLogin allows them. Users with two-factor authentication get require_two_factor and an mfa_token for /auth/2fa/verify instead. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Login",
+                "parameters": [
+                    {
+                        "description": "Authentication request",
+                        "name": "auth",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AuthReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT; binds the issued tokens to its key",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Authentication response with paseto token",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TokenOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Email address not verified",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "API to logout the current device session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout",
+                "responses": {
+                    "200": {
+                        "description": "Logout successful",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "API to logout user and invalidate all user sessions on every device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout Everywhere",
+                "responses": {
+                    "200": {
+                        "description": "Logout successful",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/magic-link": {
+            "post": {
+                "description": "Emails a single-use sign-in link to the account with this email. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Request Magic Link",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordlessReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/magic-link/verify": {
+            "post": {
+                "description": "Trades the token from a magic link for a token pair, or for an mfa_token when the user has two-factor authentication. Using the link also verifies the email address. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Verify Magic Link",
+                "parameters": [
+                    {
+                        "description": "Token from the link",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MagicLinkVerifyReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT; binds the issued tokens to its key",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Authentication response with paseto token",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TokenOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/change": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the password of the current user after checking the current one. Every other session is signed out and this one gets a fresh token pair, since the old tokens stop working. A notification is emailed to the user. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Change Password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ChangePasswordReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New token pair",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TokenOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or the password breaks the policy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponsError"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error_message": {
+                                            "$ref": "#/definitions/model.PasswordPolicyError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or wrong current password",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden while impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many incorrect current passwords",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Emails a single-use link to choose a new password to the account with this email. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Forgot Password",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordlessReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Sets a new password with the token from a reset link. Every session of the account is ended, so the user signs in again everywhere.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Reset Password",
+                "parameters": [
+                    {
+                        "description": "Token from the link and the new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ResetPasswordReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or invalid token, or the password breaks the policy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponsError"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error_message": {
+                                            "$ref": "#/definitions/model.PasswordPolicyError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "API to rotate the refresh token and issue a new token pair. Works after the access token has expired; when the access token is sent as well it must belong to the same user and session. Reusing a rotated refresh token revokes its whole family. In cookie mode both tokens are read from their cookies and the X-CSRF-Token header is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh Token",
+                "parameters": [
+                    {
+                        "description": "Refresh token request",
+                        "name": "refresh",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RefreshTokenReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT, required when the tokens are DPoP-bound",
+                        "name": "DPoP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Value of the __Host-csrf_token cookie, required in cookie mode",
+                        "name": "X-CSRF-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New access token",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TokenOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Self-service sign-up. The account gets the configured default role and an unverified email address, and a verification link is emailed to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Register",
+                "parameters": [
+                    {
+                        "description": "New account",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User registered successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or the password breaks the policy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponsError"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error_message": {
+                                            "$ref": "#/definitions/model.PasswordPolicyError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Email or username already in use",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register/resend": {
+            "post": {
+                "description": "Emails a new verification link to the account with this email if it is not verified yet. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Resend Verification Email",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordlessReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register/verify": {
+            "post": {
+                "description": "Consumes the token from a verification link and marks the account's email address as verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Verify Email",
+                "parameters": [
+                    {
+                        "description": "Token from the link",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.VerifyEmailReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/login/begin": {
+            "post": {
+                "description": "Starts a passwordless login. Pass options to navigator.credentials.get and send the result to /auth/webauthn/login/finish with the ceremony_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Begin Passkey Login",
+                "responses": {
+                    "200": {
+                        "description": "Credential request options",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.WebAuthnBeginOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/login/finish": {
+            "post": {
+                "description": "Verifies the assertion from navigator.credentials.get and issues a token pair for the passkey's owner. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Finish Passkey Login",
+                "parameters": [
+                    {
+                        "description": "Ceremony id and PublicKeyCredential",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WebAuthnLoginReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT; binds the issued tokens to its key",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Authentication response with paseto token",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TokenOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or expired ceremony",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid assertion",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Email address not verified",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/register/begin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts adding a WebAuthn passkey or security key to the current user. Pass options to navigator.credentials.create and send the result to /auth/webauthn/register/finish with the ceremony_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Begin Passkey Registration",
+                "responses": {
+                    "200": {
+                        "description": "Credential creation options",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.WebAuthnBeginOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden while impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/register/finish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verifies the new credential from navigator.credentials.create and stores it for passwordless login",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Finish Passkey Registration",
+                "parameters": [
+                    {
+                        "description": "Ceremony id and PublicKeyCredential",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WebAuthnRegisterReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Registered credential",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.WebAuthnCredential"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, expired ceremony or invalid credential",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden while impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/introspect": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "RFC 7662 token introspection for resource servers, authenticated with client credentials. Tokens for an audience the client was not granted are reported inactive.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Token Introspection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Introspection result",
+                        "schema": {
+                            "$ref": "#/definitions/model.IntrospectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "RFC 7009 token revocation, authenticated with client credentials. Revoking a refresh token also ends its session. Responds 200 even for unknown tokens; tokens for an audience the client was not granted are refused.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Token Revocation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token to revoke",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revoked or already invalid"
+                    },
+                    "400": {
+                        "description": "Token audience is not granted to this client",
+                        "schema": {
+                            "$ref": "#/definitions/model.OAuthError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/token": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "RFC 8693 token exchange, authenticated with client credentials. Trades a user's access token for a downscoped one meant for another service the client was granted; the client is recorded in the act claim. A DPoP-bound subject token yields a token bound to the same key.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Token Exchange",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:grant-type:token-exchange",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User access token to exchange",
+                        "name": "subject_token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:token-type:access_token",
+                        "name": "subject_token_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:token-type:access_token",
+                        "name": "requested_token_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service the new token is for, one of token.exchangeAudiences",
+                        "name": "audience",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated subset of the subject token's scopes",
+                        "name": "scope",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exchanged token",
+                        "schema": {
+                            "$ref": "#/definitions/model.TokenExchangeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, grant, target, scope or ungranted audience",
+                        "schema": {
+                            "$ref": "#/definitions/model.OAuthError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "API to get paginated list of users with optional search",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "List User",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Page size (default: 10, max: 100)",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search by name, email, role, or department",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of users with pagination",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponseTotal"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.User"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing token",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Insufficient permissions",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - Database or system error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Admin path to create a user with any role. The email address is treated as verified; self-service sign-up goes through /auth/register.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Create User",
+                "parameters": [
+                    {
+                        "description": "Authentication request",
+                        "name": "auth",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or the password breaks the policy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponsError"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error_message": {
+                                            "$ref": "#/definitions/model.PasswordPolicyError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Insufficient scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "409": {
+                        "description": "Email or username already in use",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get detailed user information by ID. Requires users:read, or profile:read for the caller's own ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get User by ID",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User details retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID format",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Insufficient scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update existing user information. Refused while impersonating, since changing the email, username or password would hand over the account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update User",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User update data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data, or the password breaks the policy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponsError"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error_message": {
+                                            "$ref": "#/definitions/model.PasswordPolicyError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Insufficient scope or impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "409": {
+                        "description": "Email or username already in use",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft delete user (mark as deleted)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete User",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "User ID to delete",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User deleted successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID format",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Insufficient scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.JsonResponsError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.AuthReq": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 6
+                }
+            }
+        },
+        "model.ChangePasswordReq": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "fullname",
+                "password",
+                "role_id",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "fullname": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string"
+                },
+                "role_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3
+                }
+            }
+        },
+        "model.EmailOTPVerifyReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "email"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.IntrospectionResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "aud": {
+                    "type": "string"
+                },
+                "cnf": {
+                    "$ref": "#/definitions/token.Confirmation"
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "iss": {
+                    "type": "string"
+                },
+                "jti": {
+                    "type": "string"
+                },
+                "nbf": {
+                    "type": "integer"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/token.JWK"
+                    }
+                }
+            }
+        },
+        "model.JsonResponsError": {
+            "type": "object",
+            "properties": {
+                "developer_message": {},
+                "error_code": {},
+                "error_message": {},
+                "request_id": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.JsonResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "messages": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.JsonResponseTotal": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "messages": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.MagicLinkVerifyReq": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.OAuthError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "error_description": {
+                    "type": "string"
+                }
             }
-        }
-    },
-    "definitions": {
-        "model.AuthReq": {
+        },
+        "model.PasetoKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/token.PublicKey"
+                    }
+                }
+            }
+        },
+        "model.PasswordPolicyError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PasswordViolation"
+                    }
+                }
+            }
+        },
+        "model.PasswordViolation": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PasswordlessReq": {
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "email"
             ],
             "properties": {
                 "email": {
                     "type": "string"
+                }
+            }
+        },
+        "model.RecoveryCodesOutput": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "ip_address": {
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RefreshTokenReq": {
+            "type": "object",
+            "properties": {
+                "at": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 128,
-                    "minLength": 6
+                "rt": {
+                    "type": "string"
                 }
             }
         },
-        "model.CreateUserRequest": {
+        "model.RegisterRequest": {
             "type": "object",
             "required": [
                 "email",
                 "fullname",
                 "password",
-                "role_id",
                 "username"
             ],
             "properties": {
@@ -596,12 +2585,7 @@ const docTemplate = `{
                     "maxLength": 255
                 },
                 "password": {
-                    "type": "string",
-                    "maxLength": 128,
-                    "minLength": 6
-                },
-                "role_id": {
-                    "type": "integer"
+                    "type": "string"
                 },
                 "username": {
                     "type": "string",
@@ -610,60 +2594,37 @@ const docTemplate = `{
                 }
             }
         },
-        "model.JsonResponsError": {
+        "model.ResetPasswordReq": {
             "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
             "properties": {
-                "developer_message": {},
-                "error_code": {},
-                "error_message": {},
-                "request_id": {
+                "password": {
                     "type": "string"
                 },
-                "status_code": {
-                    "type": "integer"
+                "token": {
+                    "type": "string"
                 }
             }
         },
-        "model.JsonResponse": {
+        "model.TokenExchangeResponse": {
             "type": "object",
             "properties": {
-                "data": {},
-                "messages": {
+                "access_token": {
                     "type": "string"
                 },
-                "request_id": {
-                    "type": "string"
-                },
-                "status_code": {
+                "expires_in": {
                     "type": "integer"
-                }
-            }
-        },
-        "model.JsonResponseTotal": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "messages": {
-                    "type": "string"
                 },
-                "request_id": {
+                "issued_token_type": {
                     "type": "string"
                 },
-                "status_code": {
-                    "type": "integer"
-                },
-                "total": {
-                    "type": "integer"
-                }
-            }
-        },
-        "model.RefreshTokenReq": {
-            "type": "object",
-            "properties": {
-                "at": {
+                "scope": {
                     "type": "string"
                 },
-                "rt": {
+                "token_type": {
                     "type": "string"
                 }
             }
@@ -683,6 +2644,9 @@ const docTemplate = `{
                 "enable_2fa": {
                     "type": "boolean"
                 },
+                "mfa_token": {
+                    "type": "string"
+                },
                 "r": {
                     "type": "string"
                 },
@@ -694,6 +2658,59 @@ const docTemplate = `{
                 }
             }
         },
+        "model.TwoFactorCodeReq": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "TOTP or recovery code",
+                    "type": "string"
+                }
+            }
+        },
+        "model.TwoFactorEnrollment": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "type": "string"
+                },
+                "qr_code": {
+                    "description": "PNG data URI",
+                    "type": "string"
+                },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TwoFactorVerifyReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "description": "TOTP or recovery code",
+                    "type": "string"
+                },
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
         "model.UpdateUserRequest": {
             "type": "object",
             "properties": {
@@ -706,9 +2723,7 @@ const docTemplate = `{
                     "maxLength": 255
                 },
                 "password": {
-                    "type": "string",
-                    "maxLength": 128,
-                    "minLength": 6
+                    "type": "string"
                 },
                 "role_id": {
                     "type": "integer"
@@ -732,6 +2747,9 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "email_verified": {
+                    "type": "boolean"
+                },
                 "fullname": {
                     "type": "string"
                 },
@@ -754,9 +2772,168 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "model.VerifyEmailReq": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebAuthnBeginOutput": {
+            "type": "object",
+            "properties": {
+                "ceremony_id": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "object"
+                }
+            }
+        },
+        "model.WebAuthnCredential": {
+            "type": "object",
+            "properties": {
+                "attestation_type": {
+                    "type": "string"
+                },
+                "backup_eligible": {
+                    "type": "boolean"
+                },
+                "backup_state": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "transports": {
+                    "description": "comma separated",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.WebAuthnLoginReq": {
+            "type": "object",
+            "required": [
+                "ceremony_id",
+                "credential"
+            ],
+            "properties": {
+                "ceremony_id": {
+                    "type": "string"
+                },
+                "credential": {
+                    "description": "PublicKeyCredential from navigator.credentials.get",
+                    "type": "object"
+                },
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "model.WebAuthnRegisterReq": {
+            "type": "object",
+            "required": [
+                "ceremony_id",
+                "credential"
+            ],
+            "properties": {
+                "ceremony_id": {
+                    "type": "string"
+                },
+                "credential": {
+                    "description": "PublicKeyCredential from navigator.credentials.create",
+                    "type": "object"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "token.Confirmation": {
+            "type": "object",
+            "properties": {
+                "jkt": {
+                    "type": "string"
+                }
+            }
+        },
+        "token.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "d": {
+                    "description": "only ever set on keys to reject",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "token.PublicKey": {
+            "type": "object",
+            "properties": {
+                "kid": {
+                    "type": "string"
+                },
+                "public_key": {
+                    "type": "string"
+                },
+                "purpose": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "BasicAuth": {
+            "type": "basic"
+        },
         "BearerAuth": {
             "description": "Type \"Bearer\" followed by a space and PASETO token.",
             "type": "apiKey",
@@ -773,7 +2950,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Go Auth Clean Arch API",
-	Description:      "API for You By Peter Shaan with PASETO authentication",
+	Description:      "OAuth client credentials (client_id:client_secret).",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisChallengeKeyPrefix = "challenge:"
+
+type challengeEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryChallenge keeps ceremony state on this instance only, so both
+// requests of a ceremony must reach the same instance; use RedisChallenge
+// behind a load balancer without sticky sessions.
+type MemoryChallenge struct {
+	mu      sync.Mutex
+	entries map[string]challengeEntry
+	sweepAt time.Time
+}
+
+func NewMemoryChallenge() model.ChallengeStore {
+	return &MemoryChallenge{
+		entries: make(map[string]challengeEntry),
+	}
+}
+
+func (m *MemoryChallenge) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.After(m.sweepAt) {
+		for k, entry := range m.entries {
+			if now.After(entry.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.sweepAt = now.Add(ttl)
+	}
+
+	m.entries[key] = challengeEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemoryChallenge) Take(ctx context.Context, key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	delete(m.entries, key)
+	if time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// RedisChallenge shares ceremony state between instances. Take uses
+// GETDEL, so a ceremony can only be finished once.
+type RedisChallenge struct {
+	client *redis.Client
+}
+
+func NewRedisChallenge(client *redis.Client) model.ChallengeStore {
+	return &RedisChallenge{
+		client: client,
+	}
+}
+
+func (r *RedisChallenge) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, redisChallengeKeyPrefix+key, value, ttl).Err()
+}
+
+func (r *RedisChallenge) Take(ctx context.Context, key string) ([]byte, bool) {
+	value, err := r.client.GetDel(ctx, redisChallengeKeyPrefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Println("challenge cache take error:", err.Error())
+		}
+		return nil, false
+	}
+	return value, true
+}
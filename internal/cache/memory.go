@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+type memoryEntry struct {
+	userId    int64
+	version   int
+	expiresAt time.Time
+}
+
+// Memory is a per-instance LRU cache with a TTL. Invalidations are only
+// seen by this instance, so the TTL bounds how long other instances may
+// accept a revoked session; use Redis when that window must be zero.
+type Memory struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[int64]*list.Element
+}
+
+func NewMemory(size int, ttl time.Duration) model.SessionCache {
+	return &Memory{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[int64]*list.Element, size),
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, userId int64) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[userId]
+	if !ok {
+		return 0, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.order.Remove(elem)
+		delete(m.entries, userId)
+		return 0, false
+	}
+	m.order.MoveToFront(elem)
+	return entry.version, true
+}
+
+func (m *Memory) Set(ctx context.Context, userId int64, version int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+	if elem, ok := m.entries[userId]; ok {
+		entry := elem.Value.(*memoryEntry)
+		if entry.version > version && now.Before(entry.expiresAt) {
+			return
+		}
+		entry.version = version
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return
+	}
+
+	m.entries[userId] = m.order.PushFront(&memoryEntry{
+		userId:    userId,
+		version:   version,
+		expiresAt: expiresAt,
+	})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).userId)
+	}
+}
+
+func (m *Memory) Delete(ctx context.Context, userId int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[userId]; ok {
+		m.order.Remove(elem)
+		delete(m.entries, userId)
+	}
+}
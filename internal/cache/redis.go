@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "session_version:"
+
+// redisSetIfHigher stores ARGV[1] unless a higher version is cached.
+var redisSetIfHigher = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]))
+if current and current > tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// Redis shares session versions between instances, so an invalidation on
+// one instance is seen by all of them at once. Redis errors are treated as
+// cache misses and fall through to the database.
+type Redis struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedis(client *redis.Client, ttl time.Duration) model.SessionCache {
+	return &Redis{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func redisKey(userId int64) string {
+	return redisKeyPrefix + strconv.FormatInt(userId, 10)
+}
+
+func (r *Redis) Get(ctx context.Context, userId int64) (int, bool) {
+	version, err := r.client.Get(ctx, redisKey(userId)).Int()
+	if err != nil {
+		if err != redis.Nil {
+			log.Println("session cache get error:", err.Error())
+		}
+		return 0, false
+	}
+	return version, true
+}
+
+func (r *Redis) Set(ctx context.Context, userId int64, version int) {
+	err := redisSetIfHigher.Run(ctx, r.client, []string{redisKey(userId)}, version, r.ttl.Milliseconds()).Err()
+	if err != nil {
+		log.Println("session cache set error:", err.Error())
+	}
+}
+
+func (r *Redis) Delete(ctx context.Context, userId int64) {
+	if err := r.client.Del(ctx, redisKey(userId)).Err(); err != nil {
+		log.Println("session cache delete error:", err.Error())
+	}
+}
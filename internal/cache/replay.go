@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisReplayKeyPrefix = "dpop_jti:"
+
+// MemoryReplay remembers claimed keys on this instance only. Behind a load
+// balancer a proof could be replayed once against every other instance;
+// use RedisReplay there.
+type MemoryReplay struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	sweepAt time.Time
+}
+
+func NewMemoryReplay() token.ReplayStore {
+	return &MemoryReplay{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryReplay) Claim(ctx context.Context, key string, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.After(m.sweepAt) {
+		for k, expiresAt := range m.entries {
+			if now.After(expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.sweepAt = now.Add(ttl)
+	}
+
+	if expiresAt, ok := m.entries[key]; ok && now.Before(expiresAt) {
+		return false
+	}
+	m.entries[key] = now.Add(ttl)
+	return true
+}
+
+// RedisReplay shares claimed keys between instances. When Redis is down
+// proofs are rejected, since accepting them would allow replays.
+type RedisReplay struct {
+	client *redis.Client
+}
+
+func NewRedisReplay(client *redis.Client) token.ReplayStore {
+	return &RedisReplay{
+		client: client,
+	}
+}
+
+func (r *RedisReplay) Claim(ctx context.Context, key string, ttl time.Duration) bool {
+	fresh, err := r.client.SetNX(ctx, redisReplayKeyPrefix+key, 1, ttl).Result()
+	if err != nil {
+		log.Println("replay cache claim error:", err.Error())
+		return false
+	}
+	return fresh
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 Peter Shaan <petershaan12@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/petershaan12/go-auth-clean-arch/internal/repository"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/spf13/cobra"
+)
+
+// clientsCmd represents the clients command
+var clientsCmd = &cobra.Command{
+	Use:   "clients",
+	Short: "Manage OAuth clients here",
+	Long:  "Manage the OAuth clients that may call the /oauth endpoints.",
+}
+
+// clientsCreateCmd represents the clients create command
+var clientsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Register a new OAuth client",
+	Long: `Register a new OAuth client and print its credentials.
+The secret is only stored as a bcrypt hash, so it is shown once.`,
+	Run: createClient,
+}
+
+// clientsAudiencesCmd represents the clients audiences command
+var clientsAudiencesCmd = &cobra.Command{
+	Use:   "audiences <client_id>",
+	Short: "Set the token audiences an OAuth client may handle",
+	Long: `Replace the token audiences an OAuth client may introspect and revoke.
+Pass no --audience to take every audience away from the client.`,
+	Args: cobra.ExactArgs(1),
+	Run:  setClientAudiences,
+}
+
+var (
+	clientName      string
+	clientAudiences []string
+)
+
+func init() {
+	clientsCreateCmd.Flags().StringVar(&clientName, "name", "", "Human readable client name")
+	clientsCreateCmd.Flags().StringSliceVar(&clientAudiences, "audience", nil, "Token audience the client may introspect and revoke, repeatable")
+	clientsAudiencesCmd.Flags().StringSliceVar(&clientAudiences, "audience", nil, "Token audience the client may introspect and revoke, repeatable")
+	clientsCmd.AddCommand(clientsCreateCmd)
+	clientsCmd.AddCommand(clientsAudiencesCmd)
+	rootCmd.AddCommand(clientsCmd)
+}
+
+func createClient(cmd *cobra.Command, args []string) {
+	library.ModuleConfig()
+	db, err := library.GetDatabase()
+	if err != nil {
+		log.Fatal("Failed to connect database: ", err)
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		log.Fatal("Failed to generate secret: ", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	hash, err := library.HashPassword(secret)
+	if err != nil {
+		log.Fatal("Failed to hash secret: ", err)
+	}
+
+	client, err := repository.NewOAuthClientRepository(db).WithContext(context.Background()).Create(&model.OAuthClient{
+		ClientId:     uuid.NewString(),
+		ClientSecret: hash,
+		Name:         clientName,
+		Audiences:    strings.Join(clientAudiences, " "),
+	})
+	if err != nil {
+		log.Fatal("Failed to create client: ", err)
+	}
+
+	fmt.Println("client_id:    ", client.ClientId)
+	fmt.Println("client_secret:", secret)
+	fmt.Println("audiences:    ", client.Audiences)
+}
+
+func setClientAudiences(cmd *cobra.Command, args []string) {
+	library.ModuleConfig()
+	db, err := library.GetDatabase()
+	if err != nil {
+		log.Fatal("Failed to connect database: ", err)
+	}
+
+	repo := repository.NewOAuthClientRepository(db).WithContext(context.Background())
+	if _, err := repo.FindByClientId(args[0]); err != nil {
+		log.Fatal("Failed to find client: ", err)
+	}
+	if err := repo.SetAudiences(args[0], strings.Join(clientAudiences, " ")); err != nil {
+		log.Fatal("Failed to update client: ", err)
+	}
+
+	fmt.Println("audiences:", strings.Join(clientAudiences, " "))
+}
@@ -4,16 +4,24 @@ Copyright © 2025 Peter Shaan <petershaan12@gmail.com>
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	_ "github.com/petershaan12/go-auth-clean-arch/docs"
+	"github.com/petershaan12/go-auth-clean-arch/internal/cache"
 	"github.com/petershaan12/go-auth-clean-arch/internal/controller"
+	"github.com/petershaan12/go-auth-clean-arch/internal/mailer"
 	"github.com/petershaan12/go-auth-clean-arch/internal/middleware"
 	"github.com/petershaan12/go-auth-clean-arch/internal/repository"
 	"github.com/petershaan12/go-auth-clean-arch/internal/routes"
 	"github.com/petershaan12/go-auth-clean-arch/internal/service"
 	"github.com/petershaan12/go-auth-clean-arch/internal/token"
 	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/cobra"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
@@ -33,31 +41,87 @@ func init() {
 func server(cmd *cobra.Command, args []string) {
 	env := library.ModuleConfig()
 	db, _ := library.GetDatabase()
-	requestHandler := library.NewRequestHandler()
+	requestHandler := library.NewRequestHandler(env)
 
 	dbMiddleware := middleware.NewDatabaseTrx(*requestHandler, db, env)
 
+	sessionCache, err := newSessionCache(env)
+	if err != nil {
+		log.Fatal("cannot create session cache: ", err)
+	}
 	userRepo := repository.NewUserRepository(db)
+	if sessionCache != nil {
+		userRepo = repository.NewCachedUserRepository(userRepo, sessionCache)
+	}
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	oauthClientRepo := repository.NewOAuthClientRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	userTOTPRepo := repository.NewUserTOTPRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	webAuthnCredentialRepo := repository.NewWebAuthnCredentialRepository(db)
+	loginChallengeRepo := repository.NewLoginChallengeRepository(db)
 
-	tokenMaker, err := token.NewPaseto(env.Paseto.Key, userRepo)
+	tokenMaker, err := newTokenMaker(env, token.Options{
+		Issuer:            env.Token.Issuer,
+		Audience:          env.Token.Audience,
+		ExchangeAudiences: env.Token.ExchangeAudiences,
+		SessionLimits: token.SessionLimits{
+			IdleTimeout: library.SessionIdleTimeout(),
+			Lifetime:    library.SessionAbsoluteLifetime(),
+		},
+		Stores: token.Stores{
+			Users:       userRepo,
+			Sessions:    sessionRepo,
+			Revocations: revokedTokenRepo,
+		},
+	})
 	if err != nil {
 		log.Fatal("cannot create token maker: ", err)
 	}
-	pasetoMiddleware := middleware.NewPasetoTrx(tokenMaker)
+	trustedProxies, err := library.TrustedProxies(env)
+	if err != nil {
+		log.Fatal("cannot read trustedProxies: ", err)
+	}
+	dpop := token.NewDPoP(newReplayStore(env), constants.DefaultDPoPProofWindow, trustedProxies)
+	pasetoMiddleware := middleware.NewPasetoTrx(tokenMaker, dpop, auditRepo, env)
+
+	passwordPolicy, err := service.NewPasswordPolicy(env)
+	if err != nil {
+		log.Fatal("cannot create password policy: ", err)
+	}
 
 	// User
-	userService := service.NewUserService(userRepo, env)
+	userService := service.NewUserService(userRepo, roleRepo, sessionRepo, passwordPolicy, env)
 	userController := controller.NewUserController(userService, env)
 
 	// Auth
-	authService := service.NewAuthService(userRepo, env, tokenMaker) // atau repo khusus kalau ada
-	authController := controller.NewAuthController(authService, userService, env)
+	mail, err := newMailer(env)
+	if err != nil {
+		log.Fatal("cannot create mailer: ", err)
+	}
+	twoFactorService := service.NewTwoFactorService(userTOTPRepo, recoveryCodeRepo, env)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, sessionRepo, roleRepo, auditRepo, loginChallengeRepo, revokedTokenRepo, twoFactorService, mail, passwordPolicy, env, tokenMaker) // atau repo khusus kalau ada
+	webAuthnService := service.NewWebAuthnService(webAuthnCredentialRepo, userRepo, newChallengeStore(env), env)
+	authController := controller.NewAuthController(authService, userService, twoFactorService, webAuthnService, dpop, env)
+
+	// Well-known
+	wellKnownController := controller.NewWellKnownController(tokenMaker)
 
-	routes.SetupRoutes(*requestHandler, userController, authController, dbMiddleware, pasetoMiddleware)
+	// OAuth
+	oauthService := service.NewOAuthService(oauthClientRepo, revokedTokenRepo, refreshTokenRepo, sessionRepo, env, tokenMaker)
+	oauthController := controller.NewOAuthController(oauthService, env)
+	clientAuthMiddleware := middleware.NewClientAuthMiddleware(oauthService)
+
+	routes.SetupRoutes(*requestHandler, userController, authController, wellKnownController, oauthController, dbMiddleware, pasetoMiddleware, clientAuthMiddleware)
 
 	// Swagger UI route disabled until docs package is generated with `swag init`
 	requestHandler.Echo.GET("/swagger/*", echoSwagger.WrapHandler)
 
+	go purgeRevokedTokens(revokedTokenRepo)
+
 	go func() {
 		if err := requestHandler.EchoFile.Start(":" + env.FileServerPort); err != nil {
 			log.Println("file server error:", err.Error())
@@ -68,3 +132,117 @@ func server(cmd *cobra.Command, args []string) {
 		log.Println("echo start server error:", err.Error())
 	}
 }
+
+// newTokenMaker picks the token implementation configured under token.format
+// and the matching paseto or jwt section.
+func newTokenMaker(env library.Env, opts token.Options) (token.Maker, error) {
+	switch env.Token.Format {
+	case "", "paseto":
+	case "jwt":
+		privateKey, err := os.ReadFile(env.Jwt.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read jwt private key: %w", err)
+		}
+		return token.NewJWT(env.Jwt.Algorithm, env.Jwt.KeyId, privateKey, opts)
+	default:
+		return nil, fmt.Errorf("unknown token format %q", env.Token.Format)
+	}
+
+	version := env.Paseto.Version
+	if version == "" {
+		version = token.VersionV2
+	}
+
+	switch env.Paseto.Purpose {
+	case "", "local":
+		keyring, err := newKeyring(env)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewPaseto(version, keyring, opts)
+	case "public":
+		return token.NewPasetoPublic(version, env.Paseto.KeyId, env.Paseto.PrivateKey, opts)
+	default:
+		return nil, fmt.Errorf("unknown paseto purpose %q", env.Paseto.Purpose)
+	}
+}
+
+// purgeRevokedTokens drops revocation entries once the token they block
+// has expired on its own.
+func purgeRevokedTokens(repo model.RevokedTokenMethodRepository) {
+	for range time.Tick(constants.DefaultRevocationPurgeInterval) {
+		if _, err := repo.DeleteExpired(time.Now()); err != nil {
+			log.Println("revoked token purge error:", err.Error())
+		}
+	}
+}
+
+// newSessionCache builds the session version cache configured under
+// sessionCache, or nil when caching is disabled. An unknown driver is an
+// error rather than a silent fallback to the per-instance memory cache.
+func newSessionCache(env library.Env) (model.SessionCache, error) {
+	switch env.SessionCache.Driver {
+	case "none":
+		return nil, nil
+	case "redis":
+		return cache.NewRedis(newRedisClient(env), library.SessionCacheTTL()), nil
+	case "", "memory":
+		ttl := library.SessionCacheTTL()
+		log.Printf("sessionCache: the memory driver is per instance; with more than one instance a logout or password change can take up to %s to reach the others, use the redis driver instead", ttl)
+		return cache.NewMemory(library.SessionCacheSize(), ttl), nil
+	default:
+		return nil, fmt.Errorf("unknown session cache driver %q", env.SessionCache.Driver)
+	}
+}
+
+// newReplayStore keeps spent DPoP proof ids in Redis when the session cache
+// uses it, so a proof cannot be replayed against another instance.
+func newReplayStore(env library.Env) token.ReplayStore {
+	if env.SessionCache.Driver == "redis" {
+		return cache.NewRedisReplay(newRedisClient(env))
+	}
+	return cache.NewMemoryReplay()
+}
+
+// newChallengeStore keeps WebAuthn ceremonies in Redis when the session
+// cache uses it, so begin and finish may reach different instances.
+func newChallengeStore(env library.Env) model.ChallengeStore {
+	if env.SessionCache.Driver == "redis" {
+		return cache.NewRedisChallenge(newRedisClient(env))
+	}
+	return cache.NewMemoryChallenge()
+}
+
+// newMailer builds the mailer configured under mailer. An unknown driver is
+// an error, since falling back to the console would print sign-in and
+// reset links to the server log.
+func newMailer(env library.Env) (model.Mailer, error) {
+	from := env.Mailer.From
+	if from == "" {
+		from = constants.DefaultMailFrom
+	}
+
+	switch env.Mailer.Driver {
+	case "file":
+		outboxDir := env.Mailer.OutboxDir
+		if outboxDir == "" {
+			outboxDir = constants.DefaultMailOutboxDir
+		}
+		return mailer.NewFile(outboxDir, from), nil
+	case "smtp":
+		smtp := env.Mailer.Smtp
+		return mailer.NewSMTP(smtp.Host, smtp.Port, smtp.Username, smtp.Password, from), nil
+	case "", "console":
+		return mailer.NewConsole(from), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", env.Mailer.Driver)
+	}
+}
+
+func newRedisClient(env library.Env) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     env.SessionCache.Redis.Address,
+		Password: env.SessionCache.Redis.Password,
+		DB:       env.SessionCache.Redis.Db,
+	})
+}
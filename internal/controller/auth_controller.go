@@ -1,9 +1,11 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/petershaan12/go-auth-clean-arch/internal/token"
@@ -11,31 +13,40 @@ import (
 	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
 	"github.com/petershaan12/go-auth-clean-arch/resource/model"
 	"github.com/petershaan12/go-auth-clean-arch/resource/response"
+	"gorm.io/gorm"
 )
 
 type AuthController struct {
 	service     model.AuthMethodService
 	serviceUser model.UserMethodService
+	twoFactor   model.TwoFactorMethodService
+	webAuthn    model.WebAuthnMethodService
+	dpop        *token.DPoP
 	env         library.Env
 }
 
-func NewAuthController(service model.AuthMethodService, serviceUser model.UserMethodService, env library.Env) *AuthController {
+func NewAuthController(service model.AuthMethodService, serviceUser model.UserMethodService, twoFactor model.TwoFactorMethodService, webAuthn model.WebAuthnMethodService, dpop *token.DPoP, env library.Env) *AuthController {
 	return &AuthController{
 		service:     service,
 		serviceUser: serviceUser,
+		twoFactor:   twoFactor,
+		webAuthn:    webAuthn,
+		dpop:        dpop,
 		env:         env,
 	}
 }
 
 // @Summary Login
-// @Description API to authenticate user and generate paseto token
+// @Description API to authenticate user and generate paseto token. Accounts with an unverified email are refused unless registration.unverifiedLogin allows them. Users with two-factor authentication get require_two_factor and an mfa_token for /auth/2fa/verify instead. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param auth body model.AuthReq true "Authentication request"
+// @Param DPoP header string false "DPoP proof JWT; binds the issued tokens to its key"
 // @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "Authentication response with paseto token"
 // @Failure 400 {object} model.JsonResponsError "Bad request"
 // @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Email address not verified"
 // @Failure 500 {object} model.JsonResponsError "Internal error"
 // @Router /auth/login [post]
 func (a *AuthController) Login(c echo.Context) error {
@@ -52,30 +63,75 @@ func (a *AuthController) Login(c echo.Context) error {
 
 	fmt.Println("User login attempt:", req.Email, "from IP:", req.IPAddress)
 
-	auth, err := a.service.Login(c.Request().Context(), req)
+	device, err := a.deviceInfo(c, req.DeviceLabel)
+	if err != nil {
+		log.Printf("Error in Login: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	result, err := a.service.Login(c.Request().Context(), req, device)
 	if err != nil {
 		log.Printf("Error in List: %v", err)
 		// to log who is requesting the token
 		// even if authentication fails we still log the request
+		c.Set("ip_address", req.IPAddress)
+		if errors.Is(err, model.ErrEmailNotVerified) {
+			return response.ResponseInterfaceError(c, http.StatusForbidden, err.Error(), constants.Forbidden)
+		}
 		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
 	}
 
-	result, err := a.service.GenerateToken(c.Request().Context(), auth)
-	if err != nil {
-		log.Printf("Error in List: %v", err)
-		c.Set("ip_address", req.IPAddress)
+	if err := a.setTokenCookies(c, result); err != nil {
+		log.Printf("Error in Login: %v", err)
 		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
 	}
 
 	return response.ResponseInterface(c, 200, result, "Auth")
 }
 
+// deviceInfo describes the client for a new session, bound to the key of
+// the request's DPoP proof if it sent one.
+func (a *AuthController) deviceInfo(c echo.Context, label string) (*model.DeviceInfo, error) {
+	device := &model.DeviceInfo{
+		Label:     label,
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+
+	if c.Request().Header.Get(token.DPoPHeader) != "" {
+		thumbprint, err := a.dpop.Verify(c.Request().Context(), c.Request(), "")
+		if err != nil {
+			return nil, err
+		}
+		device.DPoPThumbprint = thumbprint
+	}
+	return device, nil
+}
+
+// setTokenCookies moves the tokens from result into cookies when cookie
+// mode is on, so they never reach the page's JavaScript.
+func (a *AuthController) setTokenCookies(c echo.Context, result *model.TokenOutput) error {
+	if !a.env.Cookie.Enabled || result.AccessToken == "" {
+		return nil
+	}
+
+	err := library.SetTokenCookies(c, a.env, result.AccessToken, library.AccessTokenExpiry(), result.RefreshToken, library.RefreshTokenExpiry())
+	if err != nil {
+		return err
+	}
+	result.AccessToken = ""
+	result.RefreshToken = ""
+	return nil
+}
+
 // @Summary Refresh Token
-// @Description API to refresh paseto token
+// @Description API to rotate the refresh token and issue a new token pair. Works after the access token has expired; when the access token is sent as well it must belong to the same user and session. Reusing a rotated refresh token revokes its whole family. In cookie mode both tokens are read from their cookies and the X-CSRF-Token header is required.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param refresh body model.RefreshTokenReq true "Refresh token request"
+// @Param DPoP header string false "DPoP proof JWT, required when the tokens are DPoP-bound"
+// @Param X-CSRF-Token header string false "Value of the __Host-csrf_token cookie, required in cookie mode"
 // @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "New access token"
 // @Failure 400 {object} model.JsonResponsError "Bad request"
 // @Failure 401 {object} model.JsonResponsError "Invalid refresh token"
@@ -90,21 +146,35 @@ func (a *AuthController) RefreshToken(c echo.Context) error {
 		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
 	}
 
-	payload, err := a.service.VerifyRefreshToken(ctx, &req)
-	if err != nil {
-		log.Printf("Error in RefreshToken: %v", err)
-		return response.ResponseInterfaceError(c, http.StatusUnauthorized, "Invalid refresh token: "+err.Error(), constants.Unauthorized)
+	if c.Request().Header.Get(token.DPoPHeader) != "" {
+		thumbprint, err := a.dpop.Verify(ctx, c.Request(), "")
+		if err != nil {
+			log.Printf("Error in RefreshToken: %v", err)
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		req.DPoPThumbprint = thumbprint
 	}
 
-	user, err := a.serviceUser.GetByID(c, payload.UserId)
-	if err != nil {
-		log.Printf("Error in GetUserById: %v", err)
-		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	if a.env.Cookie.Enabled {
+		if cookie, err := c.Cookie(constants.RefreshTokenCookie); err == nil && req.RefreshToken == "" {
+			req.RefreshToken = cookie.Value
+		}
+		if cookie, err := c.Cookie(constants.AccessTokenCookie); err == nil && req.AccessToken == "" {
+			req.AccessToken = cookie.Value
+		}
+	}
+	if req.RefreshToken == "" {
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, "missing refresh token", constants.BadRequest)
 	}
 
-	tokenOutput, err := a.service.GenerateToken(ctx, user)
+	tokenOutput, err := a.service.VerifyRefreshToken(ctx, &req)
 	if err != nil {
-		log.Printf("Error in List: %v", err)
+		log.Printf("Error in RefreshToken: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusUnauthorized, "Invalid refresh token: "+err.Error(), constants.Unauthorized)
+	}
+
+	if err := a.setTokenCookies(c, tokenOutput); err != nil {
+		log.Printf("Error in RefreshToken: %v", err)
 		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
 	}
 
@@ -112,7 +182,7 @@ func (a *AuthController) RefreshToken(c echo.Context) error {
 }
 
 // @Summary Logout
-// @Description API to logout user and invalidate all user sessions
+// @Description API to logout the current device session
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -130,5 +200,796 @@ func (a *AuthController) Logout(c echo.Context) error {
 		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
 	}
 
+	if a.env.Cookie.Enabled {
+		library.ClearTokenCookies(c, a.env)
+	}
+
 	return response.ResponseInterface(c, 200, "Logout successful", "Logout")
 }
+
+// @Summary Logout Everywhere
+// @Description API to logout user and invalidate all user sessions on every device
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.JsonResponse{data=string} "Logout successful"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/logout-all [post]
+// @Security BearerAuth
+func (a *AuthController) LogoutAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	if err := a.service.LogoutAll(ctx, payload); err != nil {
+		log.Printf("Error in LogoutAll: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	if a.env.Cookie.Enabled {
+		library.ClearTokenCookies(c, a.env)
+	}
+
+	return response.ResponseInterface(c, 200, "Logout successful", "Logout")
+}
+
+// @Summary Impersonate User
+// @Description API for administrators to act as another user. Returns a short-lived access token without a refresh token; its act claim names the administrator and every request made with it is audited.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID to impersonate" minimum(1)
+// @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "Impersonation access token"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Forbidden - Not an administrator or already impersonating"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/impersonate/{id} [post]
+// @Security BearerAuth
+func (a *AuthController) Impersonate(c echo.Context) error {
+	ctx := c.Request().Context()
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, "invalid id parameter", constants.BadRequest)
+	}
+
+	device := &model.DeviceInfo{
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+
+	result, err := a.service.Impersonate(ctx, payload, id, device)
+	if err != nil {
+		log.Printf("Error in Impersonate: %v", err)
+		if errors.Is(err, model.ErrImpersonationDenied) {
+			return response.ResponseInterfaceError(c, http.StatusForbidden, err.Error(), constants.Forbidden)
+		}
+		if errors.Is(err, model.ErrImpersonateSelf) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Impersonate")
+}
+
+// @Summary Verify Two-Factor Code
+// @Description Second login step for users with two-factor authentication: trades the mfa_token from /auth/login and a code from the authenticator app, or an unused recovery code, for a token pair
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorVerifyReq true "MFA token and code"
+// @Param DPoP header string false "DPoP proof JWT; binds the issued tokens to its key"
+// @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "Authentication response with paseto token"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 401 {object} model.JsonResponsError "Invalid token or code"
+// @Failure 429 {object} model.JsonResponsError "Too many invalid codes"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/2fa/verify [post]
+func (a *AuthController) VerifyTwoFactor(c echo.Context) error {
+	var req model.TwoFactorVerifyReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in VerifyTwoFactor: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in VerifyTwoFactor: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	device, err := a.deviceInfo(c, req.DeviceLabel)
+	if err != nil {
+		log.Printf("Error in VerifyTwoFactor: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	result, err := a.service.VerifyTwoFactor(c.Request().Context(), &req, device)
+	if err != nil {
+		log.Printf("Error in VerifyTwoFactor: %v", err)
+		if errors.Is(err, model.ErrTooManyTwoFactorAttempts) {
+			return response.ResponseInterfaceError(c, http.StatusTooManyRequests, err.Error(), constants.Unauthorized)
+		}
+		return response.ResponseInterfaceError(c, http.StatusUnauthorized, err.Error(), constants.Unauthorized)
+	}
+
+	if err := a.setTokenCookies(c, result); err != nil {
+		log.Printf("Error in VerifyTwoFactor: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Auth")
+}
+
+// @Summary Enroll Two-Factor Authentication
+// @Description Creates a TOTP secret for the current user and returns it as an otpauth URI and QR code PNG, with ten single-use recovery codes. It is not required at login until confirmed with /auth/2fa/confirm.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.JsonResponse{data=model.TwoFactorEnrollment} "Secret to add to an authenticator app"
+// @Failure 400 {object} model.JsonResponsError "Already enabled"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Forbidden while impersonating"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/2fa/enroll [post]
+// @Security BearerAuth
+func (a *AuthController) EnrollTwoFactor(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	result, err := a.twoFactor.Enroll(c.Request().Context(), payload.UserId, payload.Email)
+	if err != nil {
+		log.Printf("Error in EnrollTwoFactor: %v", err)
+		if errors.Is(err, model.ErrTwoFactorAlreadyEnabled) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Two-Factor Enrollment")
+}
+
+// @Summary Confirm Two-Factor Authentication
+// @Description Turns on two-factor login after checking a first code from the enrolled authenticator app
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorCodeReq true "Code from the authenticator app"
+// @Success 200 {object} model.JsonResponse{data=string} "Two-factor authentication enabled"
+// @Failure 400 {object} model.JsonResponsError "Bad request or invalid code"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Forbidden while impersonating"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/2fa/confirm [post]
+// @Security BearerAuth
+func (a *AuthController) ConfirmTwoFactor(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	var req model.TwoFactorCodeReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in ConfirmTwoFactor: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in ConfirmTwoFactor: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.twoFactor.Confirm(c.Request().Context(), payload.UserId, req.Code); err != nil {
+		log.Printf("Error in ConfirmTwoFactor: %v", err)
+		if errors.Is(err, model.ErrInvalidTwoFactorCode) || errors.Is(err, model.ErrTwoFactorNotEnrolled) || errors.Is(err, model.ErrTwoFactorAlreadyEnabled) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "Two-factor authentication enabled", "Two-Factor Enrollment")
+}
+
+// @Summary Recovery Codes Status
+// @Description Shows how many unused two-factor recovery codes the current user has left
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.JsonResponse{data=model.RecoveryCodesOutput} "Remaining recovery codes"
+// @Failure 400 {object} model.JsonResponsError "Two-factor authentication not enabled"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/2fa/recovery-codes [get]
+// @Security BearerAuth
+func (a *AuthController) RecoveryCodes(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	result, err := a.twoFactor.CountRecoveryCodes(c.Request().Context(), payload.UserId)
+	if err != nil {
+		log.Printf("Error in RecoveryCodes: %v", err)
+		if errors.Is(err, model.ErrTwoFactorNotEnrolled) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Recovery Codes")
+}
+
+// @Summary Regenerate Recovery Codes
+// @Description Replaces all two-factor recovery codes with ten new ones after checking a current TOTP or recovery code. The new codes are only shown in this response.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorCodeReq true "Current TOTP or recovery code"
+// @Success 200 {object} model.JsonResponse{data=model.RecoveryCodesOutput} "New recovery codes"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized or invalid code"
+// @Failure 403 {object} model.JsonResponsError "Forbidden while impersonating"
+// @Failure 429 {object} model.JsonResponsError "Too many invalid codes"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/2fa/recovery-codes [post]
+// @Security BearerAuth
+func (a *AuthController) RegenerateRecoveryCodes(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	var req model.TwoFactorCodeReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in RegenerateRecoveryCodes: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in RegenerateRecoveryCodes: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	result, err := a.twoFactor.RegenerateRecoveryCodes(c.Request().Context(), payload.UserId, req.Code)
+	if err != nil {
+		log.Printf("Error in RegenerateRecoveryCodes: %v", err)
+		switch {
+		case errors.Is(err, model.ErrTooManyTwoFactorAttempts):
+			return response.ResponseInterfaceError(c, http.StatusTooManyRequests, err.Error(), constants.Unauthorized)
+		case errors.Is(err, model.ErrInvalidTwoFactorCode):
+			return response.ResponseInterfaceError(c, http.StatusUnauthorized, err.Error(), constants.Unauthorized)
+		case errors.Is(err, model.ErrTwoFactorNotEnrolled):
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Recovery Codes")
+}
+
+// @Summary Begin Passkey Registration
+// @Description Starts adding a WebAuthn passkey or security key to the current user. Pass options to navigator.credentials.create and send the result to /auth/webauthn/register/finish with the ceremony_id.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.JsonResponse{data=model.WebAuthnBeginOutput} "Credential creation options"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Forbidden while impersonating"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/webauthn/register/begin [post]
+// @Security BearerAuth
+func (a *AuthController) BeginWebAuthnRegistration(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	result, err := a.webAuthn.BeginRegistration(c.Request().Context(), payload.UserId)
+	if err != nil {
+		log.Printf("Error in BeginWebAuthnRegistration: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "WebAuthn Registration")
+}
+
+// @Summary Finish Passkey Registration
+// @Description Verifies the new credential from navigator.credentials.create and stores it for passwordless login
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.WebAuthnRegisterReq true "Ceremony id and PublicKeyCredential"
+// @Success 200 {object} model.JsonResponse{data=model.WebAuthnCredential} "Registered credential"
+// @Failure 400 {object} model.JsonResponsError "Bad request, expired ceremony or invalid credential"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Forbidden while impersonating"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/webauthn/register/finish [post]
+// @Security BearerAuth
+func (a *AuthController) FinishWebAuthnRegistration(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	var req model.WebAuthnRegisterReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in FinishWebAuthnRegistration: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in FinishWebAuthnRegistration: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	result, err := a.webAuthn.FinishRegistration(c.Request().Context(), payload.UserId, &req)
+	if err != nil {
+		log.Printf("Error in FinishWebAuthnRegistration: %v", err)
+		if errors.Is(err, model.ErrWebAuthnCeremonyNotFound) || errors.Is(err, model.ErrWebAuthnVerification) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "WebAuthn Registration")
+}
+
+// @Summary Begin Passkey Login
+// @Description Starts a passwordless login. Pass options to navigator.credentials.get and send the result to /auth/webauthn/login/finish with the ceremony_id.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.JsonResponse{data=model.WebAuthnBeginOutput} "Credential request options"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/webauthn/login/begin [post]
+func (a *AuthController) BeginWebAuthnLogin(c echo.Context) error {
+	result, err := a.webAuthn.BeginLogin(c.Request().Context())
+	if err != nil {
+		log.Printf("Error in BeginWebAuthnLogin: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "WebAuthn Login")
+}
+
+// @Summary Finish Passkey Login
+// @Description Verifies the assertion from navigator.credentials.get and issues a token pair for the passkey's owner. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.WebAuthnLoginReq true "Ceremony id and PublicKeyCredential"
+// @Param DPoP header string false "DPoP proof JWT; binds the issued tokens to its key"
+// @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "Authentication response with paseto token"
+// @Failure 400 {object} model.JsonResponsError "Bad request or expired ceremony"
+// @Failure 401 {object} model.JsonResponsError "Invalid assertion"
+// @Failure 403 {object} model.JsonResponsError "Email address not verified"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/webauthn/login/finish [post]
+func (a *AuthController) FinishWebAuthnLogin(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req model.WebAuthnLoginReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in FinishWebAuthnLogin: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in FinishWebAuthnLogin: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	device, err := a.deviceInfo(c, req.DeviceLabel)
+	if err != nil {
+		log.Printf("Error in FinishWebAuthnLogin: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	user, err := a.webAuthn.FinishLogin(ctx, &req)
+	if err != nil {
+		log.Printf("Error in FinishWebAuthnLogin: %v", err)
+		switch {
+		case errors.Is(err, model.ErrWebAuthnCeremonyNotFound):
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		case errors.Is(err, model.ErrWebAuthnVerification), errors.Is(err, model.ErrWebAuthnCredentialCloned), errors.Is(err, model.ErrWebAuthnCredentialUnknown):
+			return response.ResponseInterfaceError(c, http.StatusUnauthorized, err.Error(), constants.Unauthorized)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	result, err := a.service.GenerateToken(ctx, user, device)
+	if err != nil {
+		log.Printf("Error in FinishWebAuthnLogin: %v", err)
+		if errors.Is(err, model.ErrEmailNotVerified) {
+			return response.ResponseInterfaceError(c, http.StatusForbidden, err.Error(), constants.Forbidden)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	if err := a.setTokenCookies(c, result); err != nil {
+		log.Printf("Error in FinishWebAuthnLogin: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Auth")
+}
+
+// @Summary Request Magic Link
+// @Description Emails a single-use sign-in link to the account with this email. The response is the same whether or not the account exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.PasswordlessReq true "Account email"
+// @Success 200 {object} model.JsonResponse{data=string} "Request accepted"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/magic-link [post]
+func (a *AuthController) MagicLink(c echo.Context) error {
+	var req model.PasswordlessReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in MagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in MagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.service.SendMagicLink(c.Request().Context(), req.Email); err != nil {
+		log.Printf("Error in MagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "If the email belongs to an account, a sign-in link has been sent", "Auth")
+}
+
+// @Summary Verify Magic Link
+// @Description Trades the token from a magic link for a token pair, or for an mfa_token when the user has two-factor authentication. Using the link also verifies the email address. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.MagicLinkVerifyReq true "Token from the link"
+// @Param DPoP header string false "DPoP proof JWT; binds the issued tokens to its key"
+// @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "Authentication response with paseto token"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 401 {object} model.JsonResponsError "Invalid or expired"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/magic-link/verify [post]
+func (a *AuthController) VerifyMagicLink(c echo.Context) error {
+	var req model.MagicLinkVerifyReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in VerifyMagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in VerifyMagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	device, err := a.deviceInfo(c, req.DeviceLabel)
+	if err != nil {
+		log.Printf("Error in VerifyMagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	result, err := a.service.VerifyMagicLink(c.Request().Context(), &req, device)
+	if err != nil {
+		log.Printf("Error in VerifyMagicLink: %v", err)
+		if errors.Is(err, model.ErrInvalidLoginChallenge) {
+			return response.ResponseInterfaceError(c, http.StatusUnauthorized, err.Error(), constants.Unauthorized)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	if err := a.setTokenCookies(c, result); err != nil {
+		log.Printf("Error in VerifyMagicLink: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Auth")
+}
+
+// @Summary Request Email Sign-in Code
+// @Description Emails a six digit sign-in code to the account with this email. The response is the same whether or not the account exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.PasswordlessReq true "Account email"
+// @Success 200 {object} model.JsonResponse{data=string} "Request accepted"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/email-otp [post]
+func (a *AuthController) EmailOTP(c echo.Context) error {
+	var req model.PasswordlessReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in EmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in EmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.service.SendEmailOTP(c.Request().Context(), req.Email); err != nil {
+		log.Printf("Error in EmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "If the email belongs to an account, a sign-in code has been sent", "Auth")
+}
+
+// @Summary Verify Email Sign-in Code
+// @Description Trades the newest emailed code for a token pair, or for an mfa_token when the user has two-factor authentication. A code stops working after five wrong guesses. Using the code also verifies the email address. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.EmailOTPVerifyReq true "Email and code"
+// @Param DPoP header string false "DPoP proof JWT; binds the issued tokens to its key"
+// @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "Authentication response with paseto token"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 401 {object} model.JsonResponsError "Invalid or expired"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/email-otp/verify [post]
+func (a *AuthController) VerifyEmailOTP(c echo.Context) error {
+	var req model.EmailOTPVerifyReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in VerifyEmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in VerifyEmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	device, err := a.deviceInfo(c, req.DeviceLabel)
+	if err != nil {
+		log.Printf("Error in VerifyEmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	result, err := a.service.VerifyEmailOTP(c.Request().Context(), &req, device)
+	if err != nil {
+		log.Printf("Error in VerifyEmailOTP: %v", err)
+		if errors.Is(err, model.ErrInvalidLoginChallenge) {
+			return response.ResponseInterfaceError(c, http.StatusUnauthorized, err.Error(), constants.Unauthorized)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	if err := a.setTokenCookies(c, result); err != nil {
+		log.Printf("Error in VerifyEmailOTP: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Auth")
+}
+
+// @Summary Register
+// @Description Self-service sign-up. The account gets the configured default role and an unverified email address, and a verification link is emailed to it.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.RegisterRequest true "New account"
+// @Success 201 {object} model.JsonResponse{data=model.User} "User registered successfully"
+// @Failure 400 {object} model.JsonResponsError{error_message=model.PasswordPolicyError} "Bad request, or the password breaks the policy"
+// @Failure 409 {object} model.JsonResponsError "Email or username already in use"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/register [post]
+func (a *AuthController) Register(c echo.Context) error {
+	var req model.RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in Register: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in Register: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	tx := c.Get(constants.DBTransaction).(*gorm.DB)
+
+	result, err := a.serviceUser.Register(tx, &req)
+	if err != nil {
+		log.Printf("Error in Register: %v", err)
+		if errors.Is(err, model.ErrEmailInUse) || errors.Is(err, model.ErrUsernameInUse) {
+			return response.ResponseInterfaceError(c, http.StatusConflict, err.Error(), constants.BadRequest)
+		}
+		var policyErr *model.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, policyErr, constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	// the account exists either way; a lost email can be sent again from
+	// /auth/register/resend
+	if err := a.service.SendEmailVerification(c.Request().Context(), result.Email); err != nil {
+		log.Printf("Error in Register: %v", err)
+	}
+
+	return response.ResponseInterface(c, http.StatusCreated, result, "Auth")
+}
+
+// @Summary Verify Email
+// @Description Consumes the token from a verification link and marks the account's email address as verified.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.VerifyEmailReq true "Token from the link"
+// @Success 200 {object} model.JsonResponse{data=string} "Email verified"
+// @Failure 400 {object} model.JsonResponsError "Bad request or invalid token"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/register/verify [post]
+func (a *AuthController) VerifyEmail(c echo.Context) error {
+	var req model.VerifyEmailReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in VerifyEmail: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in VerifyEmail: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.service.VerifyEmail(c.Request().Context(), &req); err != nil {
+		log.Printf("Error in VerifyEmail: %v", err)
+		if errors.Is(err, model.ErrInvalidVerification) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "Email address verified", "Auth")
+}
+
+// @Summary Resend Verification Email
+// @Description Emails a new verification link to the account with this email if it is not verified yet. The response is the same whether or not the account exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.PasswordlessReq true "Account email"
+// @Success 200 {object} model.JsonResponse{data=string} "Request accepted"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/register/resend [post]
+func (a *AuthController) ResendVerification(c echo.Context) error {
+	var req model.PasswordlessReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in ResendVerification: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in ResendVerification: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.service.SendEmailVerification(c.Request().Context(), req.Email); err != nil {
+		log.Printf("Error in ResendVerification: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "If the email belongs to an unverified account, a verification link has been sent", "Auth")
+}
+
+// @Summary Forgot Password
+// @Description Emails a single-use link to choose a new password to the account with this email. The response is the same whether or not the account exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.PasswordlessReq true "Account email"
+// @Success 200 {object} model.JsonResponse{data=string} "Request accepted"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/password/forgot [post]
+func (a *AuthController) ForgotPassword(c echo.Context) error {
+	var req model.PasswordlessReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in ForgotPassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in ForgotPassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.service.SendPasswordReset(c.Request().Context(), req.Email); err != nil {
+		log.Printf("Error in ForgotPassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "If the email belongs to an account, a password reset link has been sent", "Auth")
+}
+
+// @Summary Reset Password
+// @Description Sets a new password with the token from a reset link. Every session of the account is ended, so the user signs in again everywhere.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ResetPasswordReq true "Token from the link and the new password"
+// @Success 200 {object} model.JsonResponse{data=string} "Password reset"
+// @Failure 400 {object} model.JsonResponsError{error_message=model.PasswordPolicyError} "Bad request or invalid token, or the password breaks the policy"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/password/reset [post]
+func (a *AuthController) ResetPassword(c echo.Context) error {
+	var req model.ResetPasswordReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in ResetPassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in ResetPassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	if err := a.service.ResetPassword(c.Request().Context(), &req); err != nil {
+		log.Printf("Error in ResetPassword: %v", err)
+		if errors.Is(err, model.ErrInvalidPasswordReset) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+		}
+		var policyErr *model.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, policyErr, constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, "Password has been reset", "Auth")
+}
+
+// @Summary Change Password
+// @Description Changes the password of the current user after checking the current one. Every other session is signed out and this one gets a fresh token pair, since the old tokens stop working. A notification is emailed to the user. In cookie mode the tokens are set as HttpOnly cookies and left out of the body.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ChangePasswordReq true "Current and new password"
+// @Success 200 {object} model.JsonResponse{data=model.TokenOutput} "New token pair"
+// @Failure 400 {object} model.JsonResponsError{error_message=model.PasswordPolicyError} "Bad request, or the password breaks the policy"
+// @Failure 401 {object} model.JsonResponsError "Unauthorized or wrong current password"
+// @Failure 403 {object} model.JsonResponsError "Forbidden while impersonating"
+// @Failure 429 {object} model.JsonResponsError "Too many incorrect current passwords"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /auth/password/change [post]
+// @Security BearerAuth
+func (a *AuthController) ChangePassword(c echo.Context) error {
+	payload := c.Get("data_paseto").(*token.Payload)
+
+	var req model.ChangePasswordReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in ChangePassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in ChangePassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	// the DPoP proof was checked by the middleware; the new tokens keep the
+	// key the current ones are bound to
+	device := &model.DeviceInfo{
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+	if payload.Confirmation != nil {
+		device.DPoPThumbprint = payload.Confirmation.JKT
+	}
+
+	result, err := a.service.ChangePassword(c.Request().Context(), payload, &req, device)
+	if err != nil {
+		log.Printf("Error in ChangePassword: %v", err)
+		if errors.Is(err, model.ErrInvalidCurrentPassword) {
+			return response.ResponseInterfaceError(c, http.StatusUnauthorized, err.Error(), constants.Unauthorized)
+		}
+		if errors.Is(err, model.ErrTooManyPasswordAttempts) {
+			return response.ResponseInterfaceError(c, http.StatusTooManyRequests, err.Error(), constants.Unauthorized)
+		}
+		var policyErr *model.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, policyErr, constants.BadRequest)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	if err := a.setTokenCookies(c, result); err != nil {
+		log.Printf("Error in ChangePassword: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return response.ResponseInterface(c, 200, result, "Auth")
+}
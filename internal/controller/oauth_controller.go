@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/petershaan12/go-auth-clean-arch/resource/response"
+)
+
+type OAuthController struct {
+	service model.OAuthMethodService
+	env     library.Env
+}
+
+func NewOAuthController(service model.OAuthMethodService, env library.Env) *OAuthController {
+	return &OAuthController{
+		service: service,
+		env:     env,
+	}
+}
+
+// @Summary Token Introspection
+// @Description RFC 7662 token introspection for resource servers, authenticated with client credentials. Tokens for an audience the client was not granted are reported inactive.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} model.IntrospectionResponse "Introspection result"
+// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Failure 401 {object} model.JsonResponsError "Invalid client credentials"
+// @Router /oauth/introspect [post]
+// @Security BasicAuth
+func (o *OAuthController) Introspect(c echo.Context) error {
+	var req model.IntrospectionReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in Introspect: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in Introspect: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	client := c.Get("oauth_client").(*model.OAuthClient)
+
+	result, err := o.service.Introspect(c.Request().Context(), client, &req)
+	if err != nil {
+		log.Printf("Error in Introspect: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	// RFC 7662 defines the body, so it is returned without the envelope
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Token Revocation
+// @Description RFC 7009 token revocation, authenticated with client credentials. Revoking a refresh token also ends its session. Responds 200 even for unknown tokens; tokens for an audience the client was not granted are refused.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 "Token revoked or already invalid"
+// @Failure 400 {object} model.OAuthError "Token audience is not granted to this client"
+// @Failure 401 {object} model.JsonResponsError "Invalid client credentials"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /oauth/revoke [post]
+// @Security BasicAuth
+func (o *OAuthController) Revoke(c echo.Context) error {
+	var req model.RevocationReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in Revoke: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, err.Error(), constants.BadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in Revoke: %v", err)
+		return response.ResponseInterfaceError(c, http.StatusBadRequest, library.GetValueBetween(err.Error(), "Error:", "tag"), constants.BadRequest)
+	}
+
+	client := c.Get("oauth_client").(*model.OAuthClient)
+
+	if err := o.service.Revoke(c.Request().Context(), client, &req); err != nil {
+		log.Printf("Error in Revoke: %v", err)
+		var oauthErr *model.OAuthError
+		if errors.As(err, &oauthErr) {
+			return c.JSON(http.StatusBadRequest, oauthErr)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// @Summary Token Exchange
+// @Description RFC 8693 token exchange, authenticated with client credentials. Trades a user's access token for a downscoped one meant for another service the client was granted; the client is recorded in the act claim. A DPoP-bound subject token yields a token bound to the same key.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "urn:ietf:params:oauth:grant-type:token-exchange"
+// @Param subject_token formData string true "User access token to exchange"
+// @Param subject_token_type formData string true "urn:ietf:params:oauth:token-type:access_token"
+// @Param requested_token_type formData string false "urn:ietf:params:oauth:token-type:access_token"
+// @Param audience formData string true "Service the new token is for, one of token.exchangeAudiences"
+// @Param scope formData string false "Space separated subset of the subject token's scopes"
+// @Success 200 {object} model.TokenExchangeResponse "Exchanged token"
+// @Failure 400 {object} model.OAuthError "Invalid request, grant, target, scope or ungranted audience"
+// @Failure 401 {object} model.JsonResponsError "Invalid client credentials"
+// @Failure 500 {object} model.JsonResponsError "Internal error"
+// @Router /oauth/token [post]
+// @Security BasicAuth
+func (o *OAuthController) Token(c echo.Context) error {
+	var req model.TokenExchangeReq
+	if err := c.Bind(&req); err != nil {
+		log.Printf("Error in Token: %v", err)
+		return c.JSON(http.StatusBadRequest, &model.OAuthError{Code: "invalid_request", Description: err.Error()})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		log.Printf("Error in Token: %v", err)
+		return c.JSON(http.StatusBadRequest, &model.OAuthError{Code: "invalid_request", Description: library.GetValueBetween(err.Error(), "Error:", "tag")})
+	}
+
+	client := c.Get("oauth_client").(*model.OAuthClient)
+
+	result, err := o.service.Exchange(c.Request().Context(), client, &req)
+	if err != nil {
+		log.Printf("Error in Token: %v", err)
+		var oauthErr *model.OAuthError
+		if errors.As(err, &oauthErr) {
+			return c.JSON(http.StatusBadRequest, oauthErr)
+		}
+		return response.ResponseInterfaceError(c, http.StatusInternalServerError, err.Error(), constants.InternalServerError)
+	}
+
+	// RFC 6749 defines the body, so it is returned without the envelope
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, result)
+}
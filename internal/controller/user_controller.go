@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -52,15 +53,17 @@ func (s *UserController) List(c echo.Context) error {
 }
 
 // @Summary Update User
-// @Description Update existing user information
+// @Description Update existing user information. Refused while impersonating, since changing the email, username or password would hand over the account.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID" minimum(1)
 // @Param body body model.UpdateUserRequest true "User update data"
 // @Success 201 {object} model.JsonResponse{data=model.User} "User updated successfully"
-// @Failure 400 {object} model.JsonResponsError "Invalid input data"
+// @Failure 400 {object} model.JsonResponsError{error_message=model.PasswordPolicyError} "Invalid input data, or the password breaks the policy"
+// @Failure 403 {object} model.JsonResponsError "Forbidden - Insufficient scope or impersonating"
 // @Failure 404 {object} model.JsonResponsError "User not found"
+// @Failure 409 {object} model.JsonResponsError "Email or username already in use"
 // @Failure 500 {object} model.JsonResponsError "Internal server error"
 // @Router /user/{id} [put]
 // @Security BearerAuth
@@ -87,6 +90,13 @@ func (s *UserController) Update(c echo.Context) error {
 
 	result, err := s.service.Update(c, tx, id, req)
 	if err != nil {
+		if errors.Is(err, model.ErrEmailInUse) || errors.Is(err, model.ErrUsernameInUse) {
+			return response.ResponseInterfaceError(c, http.StatusConflict, err.Error(), constants.BadRequest)
+		}
+		var policyErr *model.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, policyErr, constants.BadRequest)
+		}
 
 		return response.ResponseInterfaceError(c, 500, err.Error(), constants.InternalServerError)
 	}
@@ -102,6 +112,7 @@ func (s *UserController) Update(c echo.Context) error {
 // @Param id path int true "User ID to delete" minimum(1)
 // @Success 200 {object} model.JsonResponse{data=string} "User deleted successfully"
 // @Failure 400 {object} model.JsonResponsError "Invalid user ID format"
+// @Failure 403 {object} model.JsonResponsError "Forbidden - Insufficient scope"
 // @Failure 404 {object} model.JsonResponsError "User not found"
 // @Failure 500 {object} model.JsonResponsError "Internal server error"
 // @Router /user/{id} [delete]
@@ -125,13 +136,14 @@ func (s *UserController) Delete(c echo.Context) error {
 }
 
 // @Summary Get User by ID
-// @Description Get detailed user information by ID
+// @Description Get detailed user information by ID. Requires users:read, or profile:read for the caller's own ID.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID" minimum(1)
 // @Success 200 {object} model.JsonResponse{data=model.User} "User details retrieved successfully"
 // @Failure 400 {object} model.JsonResponsError "Invalid user ID format"
+// @Failure 403 {object} model.JsonResponsError "Forbidden - Insufficient scope"
 // @Failure 404 {object} model.JsonResponsError "User not found"
 // @Failure 500 {object} model.JsonResponsError "Internal server error"
 // @Router /user/{id} [get]
@@ -152,16 +164,19 @@ func (s *UserController) GetByID(c echo.Context) error {
 }
 
 // @Summary Create User
-// @Description API to create a new user
+// @Description Admin path to create a user with any role. The email address is treated as verified; self-service sign-up goes through /auth/register.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param auth body model.CreateUserRequest true "Authentication request"
-// @Success 200 {object} model.JsonResponse{data=model.User} "User registered successfully"
-// @Failure 400 {object} model.JsonResponsError "Bad request"
+// @Success 201 {object} model.JsonResponse{data=model.User} "User created successfully"
+// @Failure 400 {object} model.JsonResponsError{error_message=model.PasswordPolicyError} "Bad request, or the password breaks the policy"
 // @Failure 401 {object} model.JsonResponsError "Unauthorized"
+// @Failure 403 {object} model.JsonResponsError "Forbidden - Insufficient scope"
+// @Failure 409 {object} model.JsonResponsError "Email or username already in use"
 // @Failure 500 {object} model.JsonResponsError "Internal error"
 // @Router /user [post]
+// @Security BearerAuth
 func (s *UserController) Create(c echo.Context) error {
 	var req *model.CreateUserRequest
 
@@ -180,6 +195,13 @@ func (s *UserController) Create(c echo.Context) error {
 	result, err := s.service.Create(tx, req)
 	if err != nil {
 		log.Printf("Error in List: %v", err)
+		if errors.Is(err, model.ErrEmailInUse) || errors.Is(err, model.ErrUsernameInUse) {
+			return response.ResponseInterfaceError(c, http.StatusConflict, err.Error(), constants.BadRequest)
+		}
+		var policyErr *model.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return response.ResponseInterfaceError(c, http.StatusBadRequest, policyErr, constants.BadRequest)
+		}
 		return response.ResponseInterfaceError(c, 500, err.Error(), constants.InternalServerError)
 	}
 
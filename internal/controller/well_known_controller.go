@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+type WellKnownController struct {
+	tokenMaker token.Maker
+}
+
+func NewWellKnownController(tokenMaker token.Maker) *WellKnownController {
+	return &WellKnownController{
+		tokenMaker: tokenMaker,
+	}
+}
+
+// @Summary PASETO Public Keys
+// @Description Public keys that downstream services use to verify v2.public / v4.public tokens. Empty when tokens use a shared local key.
+// @Tags Well-Known
+// @Produce json
+// @Success 200 {object} model.PasetoKeySet "Published verification keys"
+// @Router /.well-known/paseto-keys [get]
+func (w *WellKnownController) PasetoKeys(c echo.Context) error {
+	keys := []token.PublicKey{}
+	if provider, ok := w.tokenMaker.(token.PublicKeyProvider); ok {
+		keys = provider.PublicKeys()
+	}
+
+	// served without the usual envelope so generic verifiers can consume it
+	return c.JSON(http.StatusOK, model.PasetoKeySet{Keys: keys})
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying JWT access tokens (RFC 7517). Empty unless token.format is jwt.
+// @Tags Well-Known
+// @Produce json
+// @Success 200 {object} model.JWKSet "Published JSON Web Keys"
+// @Router /.well-known/jwks.json [get]
+func (w *WellKnownController) JWKS(c echo.Context) error {
+	keys := []token.JWK{}
+	if provider, ok := w.tokenMaker.(token.JWKSProvider); ok {
+		keys = provider.JWKS()
+	}
+
+	return c.JSON(http.StatusOK, model.JWKSet{Keys: keys})
+}
@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"context"
+	"log"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+// Console writes messages to the server log instead of sending them, for
+// local development. Links and codes in them are printed in clear.
+type Console struct {
+	from string
+}
+
+func NewConsole(from string) model.Mailer {
+	return &Console{
+		from: from,
+	}
+}
+
+func (m *Console) Send(ctx context.Context, msg *model.MailMessage) error {
+	log.Printf("mail from %s to %s\nSubject: %s\n\n%s", m.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+// File writes every message to an outbox directory as an .eml file, for
+// local development and end-to-end tests that need to read the links and
+// codes that were sent.
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir string, from string) model.Mailer {
+	return &File{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *File) Send(ctx context.Context, msg *model.MailMessage) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, msg), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail to outbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+// SMTP sends messages through a mail server, authenticating with PLAIN
+// when a username is set. net/smtp upgrades to TLS when the server offers
+// STARTTLS and refuses PLAIN auth over an unencrypted connection.
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTP(host string, port int, username string, password string, from string) model.Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTP{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg *model.MailMessage) error {
+	from := m.from
+	if addr, err := mailAddress(m.from); err == nil {
+		from = addr
+	}
+	if err := smtp.SendMail(m.addr, m.auth, from, []string{msg.To}, formatMessage(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
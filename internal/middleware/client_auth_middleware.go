@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/petershaan12/go-auth-clean-arch/internal/service"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+type ClientAuthMiddleware struct {
+	oauthService model.OAuthMethodService
+}
+
+func NewClientAuthMiddleware(oauthService model.OAuthMethodService) *ClientAuthMiddleware {
+	return &ClientAuthMiddleware{
+		oauthService: oauthService,
+	}
+}
+
+// Authenticate checks OAuth client credentials, sent with HTTP Basic or as
+// client_id / client_secret form fields (RFC 6749 section 2.3.1).
+func (m *ClientAuthMiddleware) Authenticate() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			clientId, clientSecret, ok := c.Request().BasicAuth()
+			if !ok {
+				clientId = c.FormValue("client_id")
+				clientSecret = c.FormValue("client_secret")
+			}
+			if clientId == "" || clientSecret == "" {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing client credentials")
+			}
+
+			client, err := m.oauthService.AuthenticateClient(c.Request().Context(), clientId, clientSecret)
+			if err != nil {
+				if errors.Is(err, service.ErrInvalidClient) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid client credentials")
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+
+			c.Set("oauth_client", client)
+			return next(c)
+		}
+	}
+}
@@ -2,20 +2,31 @@ package middleware
 
 import (
 	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
 )
 
 type PasetoMiddleware struct {
 	tokenMaker token.Maker
+	dpop       *token.DPoP
+	auditRepo  model.AuditMethodRepository
+	env        library.Env
 }
 
-func NewPasetoTrx(tokenMaker token.Maker) *PasetoMiddleware {
+func NewPasetoTrx(tokenMaker token.Maker, dpop *token.DPoP, auditRepo model.AuditMethodRepository, env library.Env) *PasetoMiddleware {
 	return &PasetoMiddleware{
 		tokenMaker: tokenMaker,
+		dpop:       dpop,
+		auditRepo:  auditRepo,
+		env:        env,
 	}
 }
 
@@ -23,12 +34,17 @@ func (p *PasetoMiddleware) Authorize() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("authorization")
+			if authHeader == "" && p.env.Cookie.Enabled {
+				if cookie, err := c.Cookie(constants.AccessTokenCookie); err == nil {
+					authHeader = "Bearer " + cookie.Value
+				}
+			}
 			if authHeader == "" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
 			}
 
-			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-			if tokenString == authHeader {
+			scheme, tokenString, ok := strings.Cut(authHeader, " ")
+			if !ok || (scheme != "Bearer" && scheme != token.DPoPHeader) {
 				return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization format")
 			}
 
@@ -45,9 +61,137 @@ func (p *PasetoMiddleware) Authorize() echo.MiddlewareFunc {
 				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token: "+err.Error())
 			}
 
+			// refresh and mfa_pending tokens only work at their own endpoints
+			if payload.TokenType != "access" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token: not an access token")
+			}
+
+			// DPoP-bound tokens are worthless without a fresh proof from
+			// their key, so they must not be accepted as plain bearer tokens
+			if payload.Confirmation != nil {
+				if scheme != token.DPoPHeader {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token: DPoP-bound token sent as Bearer")
+				}
+				thumbprint, err := p.dpop.Verify(ctx, c.Request(), tokenString)
+				if err != nil {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `DPoP error="invalid_dpop_proof"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+				}
+				if thumbprint != payload.Confirmation.JKT {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `DPoP error="invalid_dpop_proof"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, token.ErrDPoPKeyMismatch.Error())
+				}
+			} else if scheme == token.DPoPHeader {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token: token is not DPoP-bound")
+			}
+
+			if payload.IsImpersonated() {
+				p.auditImpersonatedRequest(c, payload)
+			}
+
 			// Set payload to context
 			c.Set("data_paseto", payload)
 			return next(c)
 		}
 	}
 }
+
+// auditImpersonatedRequest records every request made with an impersonation
+// token under both the real actor and the impersonated user.
+func (p *PasetoMiddleware) auditImpersonatedRequest(c echo.Context, payload *token.Payload) {
+	audit := &model.AuditLog{
+		Action:    model.AuditImpersonatedRequest,
+		SubjectId: &payload.UserId,
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		Detail:    c.Request().Method + " " + c.Request().URL.Path,
+	}
+	if actorId, err := strconv.ParseInt(payload.Actor.Subject, 10, 64); err == nil {
+		audit.ActorId = &actorId
+	} else {
+		audit.Detail += " as " + payload.Actor.Subject // delegated to an OAuth client
+	}
+	if _, err := p.auditRepo.WithContext(c.Request().Context()).Create(audit); err != nil {
+		log.Printf("Error in auditImpersonatedRequest: %v", err)
+	}
+}
+
+// RequireScope allows the request only if the token carries every scope.
+// It must run after Authorize.
+func (p *PasetoMiddleware) RequireScope(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, ok := c.Get("data_paseto").(*token.Payload)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing token payload")
+			}
+
+			for _, scope := range scopes {
+				if !payload.HasScope(scope) {
+					return echo.NewHTTPError(http.StatusForbidden, "insufficient scope: requires "+scope)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireScopeOrSelf allows the request if the token carries scope, or
+// carries selfScope and the :id path parameter is the token's own user.
+// It must run after Authorize.
+func (p *PasetoMiddleware) RequireScopeOrSelf(scope string, selfScope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, ok := c.Get("data_paseto").(*token.Payload)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing token payload")
+			}
+
+			if payload.HasScope(scope) {
+				return next(c)
+			}
+			if payload.HasScope(selfScope) && c.Param("id") == strconv.FormatInt(payload.UserId, 10) {
+				return next(c)
+			}
+			return echo.NewHTTPError(http.StatusForbidden, "insufficient scope: requires "+scope)
+		}
+	}
+}
+
+// RequireRole allows the request only if the token's role is one of roles.
+// It must run after Authorize.
+func (p *PasetoMiddleware) RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, ok := c.Get("data_paseto").(*token.Payload)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing token payload")
+			}
+
+			for _, role := range roles {
+				if payload.Role == role {
+					return next(c)
+				}
+			}
+			return echo.NewHTTPError(http.StatusForbidden, "insufficient role")
+		}
+	}
+}
+
+// DenyImpersonation rejects tokens issued through impersonation, for
+// actions only the account owner may take. It must run after Authorize.
+func (p *PasetoMiddleware) DenyImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, ok := c.Get("data_paseto").(*token.Payload)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing token payload")
+			}
+
+			if payload.IsImpersonated() {
+				return echo.NewHTTPError(http.StatusForbidden, model.ErrImpersonationDenied.Error())
+			}
+			return next(c)
+		}
+	}
+}
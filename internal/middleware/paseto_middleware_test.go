@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+)
+
+func TestRequireScopeOrSelf(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		id     string
+		want   int
+	}{
+		{"own profile", []string{constants.ScopeProfileRead}, "7", http.StatusOK},
+		{"other user with profile scope", []string{constants.ScopeProfileRead}, "8", http.StatusForbidden},
+		{"other user with users scope", []string{constants.ScopeUsersRead}, "8", http.StatusOK},
+		{"no scope", nil, "7", http.StatusForbidden},
+	}
+
+	p := &PasetoMiddleware{}
+	handler := p.RequireScopeOrSelf(constants.ScopeUsersRead, constants.ScopeProfileRead)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/user/"+tt.id, nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			c.Set("data_paseto", &token.Payload{UserId: 7, Scopes: tt.scopes})
+
+			status := http.StatusOK
+			if err := handler(c); err != nil {
+				he, ok := err.(*echo.HTTPError)
+				if !ok {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				status = he.Code
+			}
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
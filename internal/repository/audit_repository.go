@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+type AuditRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewAuditRepository(db library.Database) model.AuditMethodRepository {
+	return &AuditRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (a *AuditRepository) WithContext(ctx context.Context) model.AuditMethodRepository {
+	return &AuditRepository{
+		db:  a.db,
+		ctx: ctx,
+	}
+}
+
+func (a *AuditRepository) Create(data *model.AuditLog) (result *model.AuditLog, err error) {
+	query := a.db.DB.WithContext(a.ctx).Table(model.AuditLogTable).Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+// CachedUserRepository puts a SessionCache in front of the session version
+// lookups done on every token check. Incrementing the version writes the
+// new one to the cache so revocation takes effect immediately; since the
+// cache never lowers a version, a concurrent reader holding the old one can
+// not put it back.
+type CachedUserRepository struct {
+	model.UserMethodRepository
+	cache model.SessionCache
+}
+
+func NewCachedUserRepository(repo model.UserMethodRepository, cache model.SessionCache) model.UserMethodRepository {
+	return &CachedUserRepository{
+		UserMethodRepository: repo,
+		cache:                cache,
+	}
+}
+
+func (u *CachedUserRepository) WithContext(ctx context.Context) model.UserMethodRepository {
+	return &CachedUserRepository{
+		UserMethodRepository: u.UserMethodRepository.WithContext(ctx),
+		cache:                u.cache,
+	}
+}
+
+func (u *CachedUserRepository) Delete(id int64) error {
+	if err := u.UserMethodRepository.Delete(id); err != nil {
+		return err
+	}
+	u.cache.Delete(context.Background(), id)
+	return nil
+}
+
+func (u *CachedUserRepository) IncrementSessionVersion(ctx context.Context, userId int64) error {
+	if err := u.UserMethodRepository.IncrementSessionVersion(ctx, userId); err != nil {
+		return err
+	}
+
+	version, err := u.UserMethodRepository.GetSessionVersion(ctx, userId)
+	if err != nil {
+		u.cache.Delete(ctx, userId)
+		return nil
+	}
+	u.cache.Set(ctx, userId, version)
+	return nil
+}
+
+func (u *CachedUserRepository) GetSessionVersion(ctx context.Context, userId int64) (int, error) {
+	if version, ok := u.cache.Get(ctx, userId); ok {
+		return version, nil
+	}
+
+	version, err := u.UserMethodRepository.GetSessionVersion(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	u.cache.Set(ctx, userId, version)
+	return version, nil
+}
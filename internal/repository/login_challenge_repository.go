@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+type LoginChallengeRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewLoginChallengeRepository(db library.Database) model.LoginChallengeMethodRepository {
+	return &LoginChallengeRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (l *LoginChallengeRepository) baseQuery() *gorm.DB {
+	return l.db.DB.WithContext(l.ctx).Table(model.LoginChallengeTable)
+}
+
+func (l *LoginChallengeRepository) WithContext(ctx context.Context) model.LoginChallengeMethodRepository {
+	return &LoginChallengeRepository{
+		db:  l.db,
+		ctx: ctx,
+	}
+}
+
+func (l *LoginChallengeRepository) Create(data *model.LoginChallenge) (result *model.LoginChallenge, err error) {
+	query := l.baseQuery().Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
+
+func (l *LoginChallengeRepository) FindActiveByHash(kind string, secretHash string) (result *model.LoginChallenge, err error) {
+	err = l.baseQuery().
+		Where("kind = ? AND secret_hash = ? AND used_at IS NULL AND expires_at > ?", kind, secretHash, time.Now()).
+		Take(&result).Error
+	return
+}
+
+// FindLatestActive returns the newest unused challenge; sending a new code
+// makes the earlier ones unusable.
+func (l *LoginChallengeRepository) FindLatestActive(userId int64, kind string) (result *model.LoginChallenge, err error) {
+	err = l.baseQuery().
+		Where("user_id = ? AND kind = ?", userId, kind).
+		Order("id DESC").
+		Take(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	if result.UsedAt != nil || !result.ExpiresAt.After(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return result, nil
+}
+
+func (l *LoginChallengeRepository) CountSince(userId int64, kind string, since time.Time) (total int64, err error) {
+	err = l.baseQuery().
+		Where("user_id = ? AND kind = ? AND created_at > ?", userId, kind, since).
+		Count(&total).Error
+	return total, err
+}
+
+// Use marks the challenge as used. It reports false when it was already
+// used, for example by a concurrent request.
+func (l *LoginChallengeRepository) Use(id int64) (bool, error) {
+	query := l.baseQuery().
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+// UseAllByUser marks every open challenge of kind for the user as used.
+func (l *LoginChallengeRepository) UseAllByUser(userId int64, kind string) error {
+	return l.baseQuery().
+		Where("user_id = ? AND kind = ? AND used_at IS NULL", userId, kind).
+		Update("used_at", time.Now()).Error
+}
+
+// ClaimAttempt counts a guess before it is checked. It reports false once
+// maxAttempts were counted, so concurrent guesses can not all slip past
+// the limit.
+func (l *LoginChallengeRepository) ClaimAttempt(id int64, maxAttempts int) (bool, error) {
+	query := l.baseQuery().
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
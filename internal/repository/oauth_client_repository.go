@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+type OAuthClientRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewOAuthClientRepository(db library.Database) model.OAuthClientMethodRepository {
+	return &OAuthClientRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (o *OAuthClientRepository) baseQuery() *gorm.DB {
+	return o.db.DB.WithContext(o.ctx).Table(model.OAuthClientTable).Where("oauth_clients.deleted_at IS NULL")
+}
+
+func (o *OAuthClientRepository) WithContext(ctx context.Context) model.OAuthClientMethodRepository {
+	return &OAuthClientRepository{
+		db:  o.db,
+		ctx: ctx,
+	}
+}
+
+func (o *OAuthClientRepository) FindByClientId(clientId string) (result *model.OAuthClient, err error) {
+	err = o.baseQuery().Where("oauth_clients.client_id = ?", clientId).First(&result).Error
+	return
+}
+
+func (o *OAuthClientRepository) Create(data *model.OAuthClient) (result *model.OAuthClient, err error) {
+	query := o.db.DB.WithContext(o.ctx).Table(model.OAuthClientTable).Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
+
+func (o *OAuthClientRepository) SetAudiences(clientId string, audiences string) error {
+	return o.baseQuery().Where("oauth_clients.client_id = ?", clientId).Update("audiences", audiences).Error
+}
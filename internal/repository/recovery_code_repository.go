@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewRecoveryCodeRepository(db library.Database) model.RecoveryCodeMethodRepository {
+	return &RecoveryCodeRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (r *RecoveryCodeRepository) baseQuery() *gorm.DB {
+	return r.db.DB.WithContext(r.ctx).Table(model.RecoveryCodeTable)
+}
+
+func (r *RecoveryCodeRepository) WithContext(ctx context.Context) model.RecoveryCodeMethodRepository {
+	return &RecoveryCodeRepository{
+		db:  r.db,
+		ctx: ctx,
+	}
+}
+
+// Replace swaps all of the user's codes for a new set in one transaction,
+// so old codes stop working the moment new ones are shown.
+func (r *RecoveryCodeRepository) Replace(userId int64, codeHashes []string) error {
+	return r.db.DB.WithContext(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(model.RecoveryCodeTable).Where("user_id = ?", userId).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]*model.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, &model.RecoveryCode{UserId: userId, CodeHash: hash})
+		}
+		return tx.Table(model.RecoveryCodeTable).Create(codes).Error
+	})
+}
+
+func (r *RecoveryCodeRepository) FindUnused(userId int64) (result []*model.RecoveryCode, err error) {
+	query := r.baseQuery().Where("user_id = ? AND used_at IS NULL", userId).Find(&result)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return result, nil
+}
+
+// MarkUsed reports false when the code was already used, for example by a
+// concurrent request.
+func (r *RecoveryCodeRepository) MarkUsed(id int64) (bool, error) {
+	query := r.baseQuery().
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (r *RecoveryCodeRepository) CountUnused(userId int64) (total int64, err error) {
+	err = r.baseQuery().Where("user_id = ? AND used_at IS NULL", userId).Count(&total).Error
+	return total, err
+}
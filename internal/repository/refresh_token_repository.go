@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewRefreshTokenRepository(db library.Database) model.RefreshTokenMethodRepository {
+	return &RefreshTokenRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (r *RefreshTokenRepository) baseQuery() *gorm.DB {
+	return r.db.DB.WithContext(r.ctx).Table(model.RefreshTokenTable)
+}
+
+func (r *RefreshTokenRepository) WithContext(ctx context.Context) model.RefreshTokenMethodRepository {
+	return &RefreshTokenRepository{
+		db:  r.db,
+		ctx: ctx,
+	}
+}
+
+func (r *RefreshTokenRepository) Create(data *model.RefreshToken) (result *model.RefreshToken, err error) {
+	query := r.baseQuery().Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
+
+func (r *RefreshTokenRepository) FindByJti(jti string) (result *model.RefreshToken, err error) {
+	err = r.baseQuery().Where("jti = ?", jti).First(&result).Error
+	return
+}
+
+// Consume marks the token as used. It reports false when the token had
+// already been consumed, which callers must treat as reuse.
+func (r *RefreshTokenRepository) Consume(jti string) (consumed bool, err error) {
+	query := r.baseQuery().
+		Where("jti = ? AND consumed = ?", jti, false).
+		Update("consumed", true)
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(familyId string) error {
+	return r.baseQuery().
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", time.Now()).Error
+}
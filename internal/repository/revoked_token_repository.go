@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevokedTokenRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewRevokedTokenRepository(db library.Database) model.RevokedTokenMethodRepository {
+	return &RevokedTokenRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (r *RevokedTokenRepository) baseQuery() *gorm.DB {
+	return r.db.DB.WithContext(r.ctx).Table(model.RevokedTokenTable)
+}
+
+func (r *RevokedTokenRepository) WithContext(ctx context.Context) model.RevokedTokenMethodRepository {
+	return &RevokedTokenRepository{
+		db:  r.db,
+		ctx: ctx,
+	}
+}
+
+// Create records the revocation; revoking the same jti twice is a no-op.
+func (r *RevokedTokenRepository) Create(data *model.RevokedToken) (result *model.RevokedToken, err error) {
+	query := r.baseQuery().Clauses(clause.OnConflict{DoNothing: true}).Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
+
+// Claim records the revocation and reports whether this call was the one
+// that made it, so a single-use token is only accepted once.
+func (r *RevokedTokenRepository) Claim(data *model.RevokedToken) (bool, error) {
+	query := r.baseQuery().Clauses(clause.OnConflict{DoNothing: true}).Create(data)
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (r *RevokedTokenRepository) DeleteExpired(now time.Time) (deleted int64, err error) {
+	query := r.baseQuery().Where("expires_at < ?", now).Delete(&model.RevokedToken{})
+	return query.RowsAffected, query.Error
+}
+
+func (r *RevokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var total int64
+	err := r.db.DB.WithContext(ctx).
+		Table(model.RevokedTokenTable).
+		Where("jti = ?", jti).
+		Count(&total).Error
+	if err != nil {
+		return false, err
+	}
+	return total > 0, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+type RoleRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewRoleRepository(db library.Database) model.RoleMethodRepository {
+	return &RoleRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (r *RoleRepository) WithContext(ctx context.Context) model.RoleMethodRepository {
+	return &RoleRepository{
+		db:  r.db,
+		ctx: ctx,
+	}
+}
+
+func (r *RoleRepository) FindByID(id int64) (result *model.Role, err error) {
+	err = r.db.DB.WithContext(r.ctx).Table(model.RoleTable).Where("id = ?", id).First(&result).Error
+	return
+}
+
+func (r *RoleRepository) FindByName(name string) (result *model.Role, err error) {
+	err = r.db.DB.WithContext(r.ctx).Table(model.RoleTable).Where("name = ?", name).First(&result).Error
+	return
+}
+
+func (r *RoleRepository) GetPermissions(roleId int64) (result []string, err error) {
+	err = r.db.DB.WithContext(r.ctx).
+		Table(model.PermissionTable).
+		Joins("join role_permissions on role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", roleId).
+		Order("permissions.name").
+		Pluck("permissions.name", &result).Error
+	return
+}
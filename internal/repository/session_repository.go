@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewSessionRepository(db library.Database) model.SessionMethodRepository {
+	return &SessionRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (s *SessionRepository) baseQuery() *gorm.DB {
+	return s.db.DB.WithContext(s.ctx).Table(model.SessionTable)
+}
+
+func (s *SessionRepository) WithContext(ctx context.Context) model.SessionMethodRepository {
+	return &SessionRepository{
+		db:  s.db,
+		ctx: ctx,
+	}
+}
+
+func (s *SessionRepository) Create(data *model.Session) (result *model.Session, err error) {
+	if data.LastSeenAt.IsZero() {
+		data.LastSeenAt = time.Now()
+	}
+	query := s.baseQuery().Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
+
+func (s *SessionRepository) Touch(sessionId string) error {
+	return s.baseQuery().
+		Where("id = ? AND revoked_at IS NULL", sessionId).
+		Update("last_seen_at", time.Now()).Error
+}
+
+func (s *SessionRepository) Revoke(sessionId string) error {
+	return s.baseQuery().
+		Where("id = ? AND revoked_at IS NULL", sessionId).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *SessionRepository) RevokeAllByUser(userId int64) error {
+	return s.baseQuery().
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *SessionRepository) RevokeOthersByUser(userId int64, keepSessionId string) error {
+	return s.baseQuery().
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userId, keepSessionId).
+		Update("revoked_at", time.Now()).Error
+}
+
+// GetSessionActivity returns nil without error for unknown sessions, which
+// are treated like revoked ones.
+func (s *SessionRepository) GetSessionActivity(ctx context.Context, sessionId string) (*token.SessionActivity, error) {
+	var session model.Session
+	err := s.db.DB.WithContext(ctx).
+		Table(model.SessionTable).
+		Select("created_at", "last_seen_at", "revoked_at").
+		Where("id = ?", sessionId).
+		Take(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token.SessionActivity{
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		RevokedAt:  session.RevokedAt,
+	}, nil
+}
+
+func (s *SessionRepository) MarkSessionSeen(ctx context.Context, sessionId string) error {
+	return s.WithContext(ctx).Touch(sessionId)
+}
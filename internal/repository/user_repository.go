@@ -55,7 +55,7 @@ func (u *UserRepository) FindAllBy(conditions []*model.GormWhere) (result []*mod
 }
 
 func (u *UserRepository) queryJoinHidePassword(query *gorm.DB) *gorm.DB {
-	return query.Select("users.id, users.username, users.email, users.fullname, users.role_id, users.created_at, users.updated_at, users.deleted_at, roles.name as role_name").
+	return query.Select("users.id, users.username, users.email, users.email_verified, users.fullname, users.role_id, users.created_at, users.updated_at, users.deleted_at, roles.name as role_name").
 		Joins("left join roles on roles.id = users.role_id").Where("users.deleted_at IS NULL")
 }
 
@@ -84,6 +84,43 @@ func (u *UserRepository) Delete(id int64) (err error) {
 	return query.Error
 }
 
+func (u *UserRepository) MarkEmailVerified(id int64) error {
+	return u.db.DB.Table(model.UserTable).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("email_verified", true).Error
+}
+
+func (u *UserRepository) UpdatePassword(id int64, passwordHash string) error {
+	return u.db.DB.Table(model.UserTable).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("password", passwordHash).Error
+}
+
+// ClaimPasswordAttempt counts a current password check before it is made.
+// It reports false once maxAttempts were counted since lockedSince, so
+// concurrent guesses can not all slip past the limit. An older streak
+// starts over.
+func (u *UserRepository) ClaimPasswordAttempt(id int64, maxAttempts int, lockedSince time.Time) (bool, error) {
+	// gorm sets map columns in key order, so password_failed_attempts
+	// still sees the previous password_last_failed_at
+	query := u.db.DB.Table(model.UserTable).
+		Where("id = ? AND deleted_at IS NULL AND (password_failed_attempts < ? OR password_last_failed_at IS NULL OR password_last_failed_at < ?)", id, maxAttempts, lockedSince).
+		Updates(map[string]any{
+			"password_failed_attempts": gorm.Expr("CASE WHEN password_last_failed_at IS NULL OR password_last_failed_at < ? THEN 1 ELSE password_failed_attempts + 1 END", lockedSince),
+			"password_last_failed_at":  time.Now(),
+		})
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (u *UserRepository) ResetPasswordAttempts(id int64) error {
+	return u.db.DB.Table(model.UserTable).
+		Where("id = ?", id).
+		Update("password_failed_attempts", 0).Error
+}
+
 func (u *UserRepository) FindBy(filter []*model.GormWhere) (result *model.User, err error) {
 	query := u.baseQuery()
 	// apply filters
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserTOTPRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewUserTOTPRepository(db library.Database) model.UserTOTPMethodRepository {
+	return &UserTOTPRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (u *UserTOTPRepository) baseQuery() *gorm.DB {
+	return u.db.DB.WithContext(u.ctx).Table(model.UserTOTPTable)
+}
+
+func (u *UserTOTPRepository) WithContext(ctx context.Context) model.UserTOTPMethodRepository {
+	return &UserTOTPRepository{
+		db:  u.db,
+		ctx: ctx,
+	}
+}
+
+func (u *UserTOTPRepository) FindByUserId(userId int64) (result *model.UserTOTP, err error) {
+	query := u.baseQuery().Where("user_id = ?", userId).Take(&result)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return result, nil
+}
+
+// Save stores a new, unconfirmed secret, replacing any earlier enrollment.
+func (u *UserTOTPRepository) Save(data *model.UserTOTP) (result *model.UserTOTP, err error) {
+	query := u.baseQuery().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_used_step", "failed_attempts", "last_failed_at"}),
+	}).Create(data)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return data, nil
+}
+
+func (u *UserTOTPRepository) Confirm(userId int64, step int64) error {
+	return u.baseQuery().
+		Where("user_id = ? AND confirmed_at IS NULL", userId).
+		Updates(map[string]any{
+			"confirmed_at":   time.Now(),
+			"last_used_step": step,
+		}).Error
+}
+
+// UseStep records step as used. It reports false when step or a later one
+// was already used, so the same code can not log in twice.
+func (u *UserTOTPRepository) UseStep(userId int64, step int64) (bool, error) {
+	query := u.baseQuery().
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+// ClaimAttempt counts an attempt before its code is checked. It reports
+// false once maxAttempts were counted since lockedSince, so concurrent
+// guesses can not all slip past the limit. An older streak starts over.
+func (u *UserTOTPRepository) ClaimAttempt(userId int64, maxAttempts int, lockedSince time.Time) (bool, error) {
+	// gorm sets map columns in key order, so failed_attempts still sees
+	// the previous last_failed_at
+	query := u.baseQuery().
+		Where("user_id = ? AND (failed_attempts < ? OR last_failed_at IS NULL OR last_failed_at < ?)", userId, maxAttempts, lockedSince).
+		Updates(map[string]any{
+			"failed_attempts": gorm.Expr("CASE WHEN last_failed_at IS NULL OR last_failed_at < ? THEN 1 ELSE failed_attempts + 1 END", lockedSince),
+			"last_failed_at":  time.Now(),
+		})
+	if query.Error != nil {
+		return false, query.Error
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (u *UserTOTPRepository) ResetAttempts(userId int64) error {
+	return u.baseQuery().
+		Where("user_id = ?", userId).
+		Update("failed_attempts", 0).Error
+}
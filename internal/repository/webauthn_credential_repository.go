@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+type WebAuthnCredentialRepository struct {
+	db  library.Database
+	ctx context.Context
+}
+
+func NewWebAuthnCredentialRepository(db library.Database) model.WebAuthnCredentialMethodRepository {
+	return &WebAuthnCredentialRepository{
+		db:  db,
+		ctx: context.Background(),
+	}
+}
+
+func (w *WebAuthnCredentialRepository) baseQuery() *gorm.DB {
+	return w.db.DB.WithContext(w.ctx).Table(model.WebAuthnCredentialTable)
+}
+
+func (w *WebAuthnCredentialRepository) WithContext(ctx context.Context) model.WebAuthnCredentialMethodRepository {
+	return &WebAuthnCredentialRepository{
+		db:  w.db,
+		ctx: ctx,
+	}
+}
+
+func (w *WebAuthnCredentialRepository) FindByUserId(userId int64) (result []*model.WebAuthnCredential, err error) {
+	query := w.baseQuery().Where("user_id = ?", userId).Order("id").Find(&result)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return result, nil
+}
+
+func (w *WebAuthnCredentialRepository) Create(req *model.WebAuthnCredential) (result *model.WebAuthnCredential, err error) {
+	query := w.baseQuery().Create(req)
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	return req, nil
+}
+
+// RecordUse stores the state the authenticator reported at a login.
+func (w *WebAuthnCredentialRepository) RecordUse(id int64, signCount uint32, backupState bool) error {
+	return w.baseQuery().
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"sign_count":   signCount,
+			"backup_state": backupState,
+			"last_used_at": time.Now(),
+		}).Error
+}
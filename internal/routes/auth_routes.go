@@ -4,6 +4,7 @@ import (
 	"github.com/petershaan12/go-auth-clean-arch/internal/controller"
 	"github.com/petershaan12/go-auth-clean-arch/internal/middleware"
 	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
 )
 
 type AuthRoutes struct {
@@ -16,10 +17,35 @@ type AuthRoutes struct {
 func (s *AuthRoutes) Setup() {
 	api := s.handler.Echo.Group("/auth")
 	api.POST("/login", s.authController.Login, s.middlewareDB.HandlerDB())
+	api.POST("/refresh", s.authController.RefreshToken, s.middlewareDB.HandlerDB())
+	api.POST("/2fa/verify", s.authController.VerifyTwoFactor, s.middlewareDB.HandlerDB())
+	api.POST("/magic-link", s.authController.MagicLink, s.middlewareDB.HandlerDB())
+	api.POST("/magic-link/verify", s.authController.VerifyMagicLink, s.middlewareDB.HandlerDB())
+	api.POST("/email-otp", s.authController.EmailOTP, s.middlewareDB.HandlerDB())
+	api.POST("/email-otp/verify", s.authController.VerifyEmailOTP, s.middlewareDB.HandlerDB())
+	api.POST("/register", s.authController.Register, s.middlewareDB.HandlerDB())
+	api.POST("/register/verify", s.authController.VerifyEmail, s.middlewareDB.HandlerDB())
+	api.POST("/register/resend", s.authController.ResendVerification, s.middlewareDB.HandlerDB())
+	api.POST("/password/forgot", s.authController.ForgotPassword, s.middlewareDB.HandlerDB())
+	api.POST("/password/reset", s.authController.ResetPassword, s.middlewareDB.HandlerDB())
+	api.POST("/webauthn/login/begin", s.authController.BeginWebAuthnLogin)
+	api.POST("/webauthn/login/finish", s.authController.FinishWebAuthnLogin, s.middlewareDB.HandlerDB())
 
 	protected := api.Group("", s.pasetoMiddleware.Authorize())
 	protected.POST("/logout", s.authController.Logout, s.middlewareDB.HandlerDB())
-	protected.POST("/refresh", s.authController.RefreshToken, s.middlewareDB.HandlerDB())
+	protected.POST("/logout-all", s.authController.LogoutAll, s.middlewareDB.HandlerDB())
+	protected.POST("/password/change", s.authController.ChangePassword, s.pasetoMiddleware.DenyImpersonation(), s.middlewareDB.HandlerDB())
+	protected.POST("/2fa/enroll", s.authController.EnrollTwoFactor, s.pasetoMiddleware.DenyImpersonation(), s.middlewareDB.HandlerDB())
+	protected.POST("/2fa/confirm", s.authController.ConfirmTwoFactor, s.pasetoMiddleware.DenyImpersonation(), s.middlewareDB.HandlerDB())
+	protected.GET("/2fa/recovery-codes", s.authController.RecoveryCodes, s.middlewareDB.HandlerDB())
+	protected.POST("/2fa/recovery-codes", s.authController.RegenerateRecoveryCodes, s.pasetoMiddleware.DenyImpersonation(), s.middlewareDB.HandlerDB())
+	protected.POST("/webauthn/register/begin", s.authController.BeginWebAuthnRegistration, s.pasetoMiddleware.DenyImpersonation(), s.middlewareDB.HandlerDB())
+	protected.POST("/webauthn/register/finish", s.authController.FinishWebAuthnRegistration, s.pasetoMiddleware.DenyImpersonation(), s.middlewareDB.HandlerDB())
+	protected.POST("/impersonate/:id", s.authController.Impersonate,
+		s.pasetoMiddleware.DenyImpersonation(),
+		s.pasetoMiddleware.RequireRole(constants.RoleSuperAdmin),
+		s.pasetoMiddleware.RequireScope(constants.ScopeUsersImpersonate),
+		s.middlewareDB.HandlerDB())
 }
 
 func NewAuthRoutes(
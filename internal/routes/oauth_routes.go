@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/petershaan12/go-auth-clean-arch/internal/controller"
+	"github.com/petershaan12/go-auth-clean-arch/internal/middleware"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+)
+
+type OAuthRoutes struct {
+	handler              library.RequestHandler
+	oauthController      *controller.OAuthController
+	clientAuthMiddleware *middleware.ClientAuthMiddleware
+}
+
+func (s *OAuthRoutes) Setup() {
+	api := s.handler.Echo.Group("/oauth", s.clientAuthMiddleware.Authenticate())
+	api.POST("/introspect", s.oauthController.Introspect)
+	api.POST("/revoke", s.oauthController.Revoke)
+	api.POST("/token", s.oauthController.Token)
+}
+
+func NewOAuthRoutes(
+	handler library.RequestHandler,
+	oauthController *controller.OAuthController,
+	clientAuthMiddleware *middleware.ClientAuthMiddleware,
+) *OAuthRoutes {
+	return &OAuthRoutes{
+		handler:              handler,
+		oauthController:      oauthController,
+		clientAuthMiddleware: clientAuthMiddleware,
+	}
+}
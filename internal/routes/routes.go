@@ -16,13 +16,18 @@ func SetupRoutes(
 	handler library.RequestHandler,
 	userController *controller.UserController,
 	authController *controller.AuthController,
+	wellKnownController *controller.WellKnownController,
+	oauthController *controller.OAuthController,
 	dbMiddleware *middleware.DBMiddleware,
 	pasetoMiddleware *middleware.PasetoMiddleware,
+	clientAuthMiddleware *middleware.ClientAuthMiddleware,
 ) {
 	// Buat slice berisi semua route module
 	routes := Routes{
 		NewUserRoutes(handler, userController, dbMiddleware, pasetoMiddleware),
 		NewAuthRoutes(handler, authController, dbMiddleware, pasetoMiddleware),
+		NewWellKnownRoutes(handler, wellKnownController),
+		NewOAuthRoutes(handler, oauthController, clientAuthMiddleware),
 		// Tambah module lain di sini jika ada
 	}
 
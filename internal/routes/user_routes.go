@@ -4,6 +4,7 @@ import (
 	"github.com/petershaan12/go-auth-clean-arch/internal/controller"
 	"github.com/petershaan12/go-auth-clean-arch/internal/middleware"
 	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
 )
 
 type UserRoutes struct {
@@ -15,13 +16,17 @@ type UserRoutes struct {
 
 func (s *UserRoutes) Setup() {
 	api := s.handler.Echo.Group("/user")
-	api.GET("", s.userController.List, s.middlewareDB.HandlerDB())
 	protected := api.Group("", s.pasetoMiddleware.Authorize())
-	protected.GET("", s.userController.List, s.middlewareDB.HandlerDB())
-	api.POST("", s.userController.Create, s.middlewareDB.HandlerDB())
-	protected.PATCH("/:id", s.userController.Update, s.middlewareDB.HandlerDB())
-	protected.DELETE("/:id", s.userController.Delete, s.middlewareDB.HandlerDB())
-	protected.GET("/:id", s.userController.GetByID, s.middlewareDB.HandlerDB())
+	protected.GET("", s.userController.List, s.pasetoMiddleware.RequireScope(constants.ScopeUsersRead), s.middlewareDB.HandlerDB())
+	protected.POST("", s.userController.Create, s.pasetoMiddleware.RequireScope(constants.ScopeUsersWrite), s.middlewareDB.HandlerDB())
+	protected.PATCH("/:id", s.userController.Update,
+		s.pasetoMiddleware.DenyImpersonation(),
+		s.pasetoMiddleware.RequireScope(constants.ScopeUsersWrite),
+		s.middlewareDB.HandlerDB())
+	protected.DELETE("/:id", s.userController.Delete, s.pasetoMiddleware.RequireScope(constants.ScopeUsersDelete), s.middlewareDB.HandlerDB())
+	protected.GET("/:id", s.userController.GetByID,
+		s.pasetoMiddleware.RequireScopeOrSelf(constants.ScopeUsersRead, constants.ScopeProfileRead),
+		s.middlewareDB.HandlerDB())
 }
 
 func NewUserRoutes(
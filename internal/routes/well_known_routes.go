@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/petershaan12/go-auth-clean-arch/internal/controller"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+)
+
+type WellKnownRoutes struct {
+	handler             library.RequestHandler
+	wellKnownController *controller.WellKnownController
+}
+
+func (s *WellKnownRoutes) Setup() {
+	api := s.handler.Echo.Group("/.well-known")
+	api.GET("/paseto-keys", s.wellKnownController.PasetoKeys)
+	api.GET("/jwks.json", s.wellKnownController.JWKS)
+}
+
+func NewWellKnownRoutes(
+	handler library.RequestHandler,
+	wellKnownController *controller.WellKnownController,
+) *WellKnownRoutes {
+	return &WellKnownRoutes{
+		handler:             handler,
+		wellKnownController: wellKnownController,
+	}
+}
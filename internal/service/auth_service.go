@@ -2,32 +2,74 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/petershaan12/go-auth-clean-arch/internal/token"
 	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
 	"github.com/petershaan12/go-auth-clean-arch/resource/model"
 	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	repo       model.UserMethodRepository
-	env        library.Env
-	tokenMaker token.Maker
+	repo          model.UserMethodRepository
+	refreshRepo   model.RefreshTokenMethodRepository
+	sessionRepo   model.SessionMethodRepository
+	roleRepo      model.RoleMethodRepository
+	auditRepo     model.AuditMethodRepository
+	challengeRepo model.LoginChallengeMethodRepository
+	revokedRepo   model.RevokedTokenMethodRepository
+	twoFactor     model.TwoFactorMethodService
+	policy        model.PasswordPolicy
+	mailer        model.Mailer
+	env           library.Env
+	tokenMaker    token.Maker
 }
 
-func NewAuthService(repo model.UserMethodRepository, env library.Env, tokenMaker token.Maker) model.AuthMethodService {
+func NewAuthService(
+	repo model.UserMethodRepository,
+	refreshRepo model.RefreshTokenMethodRepository,
+	sessionRepo model.SessionMethodRepository,
+	roleRepo model.RoleMethodRepository,
+	auditRepo model.AuditMethodRepository,
+	challengeRepo model.LoginChallengeMethodRepository,
+	revokedRepo model.RevokedTokenMethodRepository,
+	twoFactor model.TwoFactorMethodService,
+	mailer model.Mailer,
+	policy model.PasswordPolicy,
+	env library.Env,
+	tokenMaker token.Maker,
+) model.AuthMethodService {
 	return &AuthService{
-		repo:       repo,
-		env:        env,
-		tokenMaker: tokenMaker,
+		repo:          repo,
+		refreshRepo:   refreshRepo,
+		sessionRepo:   sessionRepo,
+		roleRepo:      roleRepo,
+		auditRepo:     auditRepo,
+		challengeRepo: challengeRepo,
+		revokedRepo:   revokedRepo,
+		twoFactor:     twoFactor,
+		mailer:        mailer,
+		policy:        policy,
+		env:           env,
+		tokenMaker:    tokenMaker,
 	}
 }
 
-func (a AuthService) Login(ctx context.Context, req *model.AuthReq) (result *model.User, err error) {
+// Login checks the password. Users with two-factor authentication get an
+// mfa_pending token to finish at VerifyTwoFactor instead of a token pair.
+func (a AuthService) Login(ctx context.Context, req *model.AuthReq, device *model.DeviceInfo) (*model.TokenOutput, error) {
 	// cari user (include password)
 	filter := []*model.GormWhere{
 		{
@@ -35,7 +77,7 @@ func (a AuthService) Login(ctx context.Context, req *model.AuthReq) (result *mod
 			Value: []any{req.Email},
 		},
 	}
-	result, err = a.repo.WithContext(ctx).FindBy(filter)
+	result, err := a.repo.WithContext(ctx).FindBy(filter)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("user not found")
@@ -50,47 +92,567 @@ func (a AuthService) Login(ctx context.Context, req *model.AuthReq) (result *mod
 		return nil, errors.New("invalid credentials")
 	}
 
-	return result, nil
+	return a.completeLogin(ctx, result, device)
 }
 
-func (a AuthService) GenerateToken(ctx context.Context, user *model.User) (result *model.TokenOutput, err error) {
+// checkEmailVerified refuses users who have not verified their email
+// unless registration.unverifiedLogin is limited or allow.
+func (a AuthService) checkEmailVerified(user *model.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+	switch a.env.Registration.UnverifiedLogin {
+	case constants.UnverifiedLoginLimited, constants.UnverifiedLoginAllow:
+		return nil
+	default:
+		return model.ErrEmailNotVerified
+	}
+}
 
-	roleStr := strconv.FormatInt(int64(user.RoleId), 10)
-	accessTokenExpiry := library.AccessTokenExpiry()
-	refreshTokenExpiry := library.RefreshTokenExpiry()
+// markEmailVerified records that a user who signed in with a code or link
+// sent to their inbox owns the address.
+func (a AuthService) markEmailVerified(ctx context.Context, user *model.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+	if err := a.repo.WithContext(ctx).MarkEmailVerified(user.Id); err != nil {
+		return err
+	}
+	user.EmailVerified = true
+	return nil
+}
 
-	accessToken, err := a.tokenMaker.CreateToken(
-		user.Id,
-		user.Email,
-		roleStr,
-		accessTokenExpiry,
-	)
+// completeLogin finishes a first factor: it starts the second factor when
+// the user has one, and issues tokens otherwise. Every first factor goes
+// through it, so it is where unverified users are refused.
+func (a AuthService) completeLogin(ctx context.Context, user *model.User, device *model.DeviceInfo) (*model.TokenOutput, error) {
+	if err := a.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	enabled, err := a.twoFactor.IsEnabled(ctx, user.Id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create access token: %w", err)
+		return nil, err
+	}
+	if !enabled {
+		return a.GenerateToken(ctx, user, device)
 	}
 
-	refreshToken, err := a.tokenMaker.CreateRefreshToken(
-		user.Id,
-		user.Email,
-		roleStr,
-		refreshTokenExpiry,
-	)
+	expiry := library.MFAPendingTokenExpiry()
+	mfaToken, payload, err := a.tokenMaker.CreateMFAPendingToken(ctx, &token.TokenParams{
+		UserID: user.Id,
+		Email:  user.Email,
+		RoleId: strconv.FormatInt(user.RoleId, 10),
+	}, expiry)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+		return nil, fmt.Errorf("failed to create mfa token: %w", err)
 	}
 
-	result = &model.TokenOutput{
-		BearerType:       "Bearer",
-		AccessToken:      accessToken,
-		RefreshToken:     refreshToken,
-		ExpiredToken:     time.Now().Add(accessTokenExpiry).Format(time.RFC3339),
+	return &model.TokenOutput{
+		MFAToken:         mfaToken,
+		ExpiredToken:     payload.ExpiredAt.Format(time.RFC3339),
 		UserId:           int(user.Id),
-		RequireTwoFactor: false,
+		RequireTwoFactor: true,
+		Enable2FA:        true,
+	}, nil
+}
+
+// VerifyTwoFactor is the second login step: it trades an mfa_pending token
+// and a valid code for a token pair. The mfa_pending token is revoked once
+// a code checks out, so it opens a single session.
+func (a AuthService) VerifyTwoFactor(ctx context.Context, req *model.TwoFactorVerifyReq, device *model.DeviceInfo) (*model.TokenOutput, error) {
+	payload, err := a.tokenMaker.VerifyToken(ctx, req.MFAToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mfa token: %w", err)
+	}
+	if payload.TokenType != "mfa_pending" {
+		return nil, fmt.Errorf("invalid token type: expected 'mfa_pending', got '%s'", payload.TokenType)
+	}
+
+	if err := a.twoFactor.Check(ctx, payload.UserId, req.Code); err != nil {
+		return nil, err
+	}
+
+	claimed, err := a.revokedRepo.WithContext(ctx).Claim(&model.RevokedToken{
+		Jti:       payload.ID,
+		UserId:    payload.UserId,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, model.ErrMFATokenUsed
+	}
+
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{payload.UserId}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	result, err := a.GenerateToken(ctx, user, device)
+	if err != nil {
+		return nil, err
+	}
+	result.Enable2FA = true
+	return result, nil
+}
+
+// SendMagicLink emails a single-use sign-in link. It returns as soon as
+// the account is looked up and nil whether or not email belongs to one,
+// so neither the answer nor its timing reveals the account.
+func (a AuthService) SendMagicLink(ctx context.Context, email string) error {
+	user, err := a.findUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	go a.deliverLoginChallenge(context.WithoutCancel(ctx), user, model.LoginChallengeMagicLink)
+	return nil
+}
+
+// VerifyMagicLink trades the token from a magic link for a login. Users
+// with two-factor authentication still get the mfa_pending step.
+func (a AuthService) VerifyMagicLink(ctx context.Context, req *model.MagicLinkVerifyReq, device *model.DeviceInfo) (*model.TokenOutput, error) {
+	challenge, err := a.challengeRepo.WithContext(ctx).FindActiveByHash(model.LoginChallengeMagicLink, hashLoginToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrInvalidLoginChallenge
+		}
+		return nil, err
+	}
+
+	used, err := a.challengeRepo.WithContext(ctx).Use(challenge.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, model.ErrInvalidLoginChallenge
+	}
+
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{challenge.UserId}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrInvalidLoginChallenge
+		}
+		return nil, err
+	}
+	if err := a.markEmailVerified(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return a.completeLogin(ctx, user, device)
+}
+
+// SendEmailOTP emails a six digit sign-in code, answering like
+// SendMagicLink whether or not the account exists.
+func (a AuthService) SendEmailOTP(ctx context.Context, email string) error {
+	user, err := a.findUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	go a.deliverLoginChallenge(context.WithoutCancel(ctx), user, model.LoginChallengeEmailOTP)
+	return nil
+}
+
+// VerifyEmailOTP checks a code against the newest one sent to the email.
+// A code stops working after DefaultEmailOTPMaxAttempts guesses; each is
+// counted before the code is compared.
+func (a AuthService) VerifyEmailOTP(ctx context.Context, req *model.EmailOTPVerifyReq, device *model.DeviceInfo) (*model.TokenOutput, error) {
+	user, err := a.findUserByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, model.ErrInvalidLoginChallenge
+	}
+
+	challenge, err := a.challengeRepo.WithContext(ctx).FindLatestActive(user.Id, model.LoginChallengeEmailOTP)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrInvalidLoginChallenge
+		}
+		return nil, err
+	}
+
+	claimed, err := a.challengeRepo.WithContext(ctx).ClaimAttempt(challenge.Id, constants.DefaultEmailOTPMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed || !library.CheckPasswordHash(req.Code, challenge.SecretHash) {
+		return nil, model.ErrInvalidLoginChallenge
+	}
+
+	used, err := a.challengeRepo.WithContext(ctx).Use(challenge.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, model.ErrInvalidLoginChallenge
+	}
+	if err := a.markEmailVerified(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return a.completeLogin(ctx, user, device)
+}
+
+// SendEmailVerification emails a link that confirms the account's address.
+// Like SendMagicLink it answers nil for unknown emails, and also for
+// addresses that are already verified.
+func (a AuthService) SendEmailVerification(ctx context.Context, email string) error {
+	user, err := a.findUserByEmail(ctx, email)
+	if err != nil || user == nil || user.EmailVerified {
+		return err
+	}
+
+	go a.deliverLoginChallenge(context.WithoutCancel(ctx), user, model.LoginChallengeEmailVerification)
+	return nil
+}
+
+// VerifyEmail consumes the token from a verification link and marks the
+// address as verified.
+func (a AuthService) VerifyEmail(ctx context.Context, req *model.VerifyEmailReq) error {
+	challenge, err := a.challengeRepo.WithContext(ctx).FindActiveByHash(model.LoginChallengeEmailVerification, hashLoginToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrInvalidVerification
+		}
+		return err
+	}
+
+	used, err := a.challengeRepo.WithContext(ctx).Use(challenge.Id)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return model.ErrInvalidVerification
+	}
+
+	return a.repo.WithContext(ctx).MarkEmailVerified(challenge.UserId)
+}
+
+// SendPasswordReset emails a single-use link to choose a new password,
+// answering like SendMagicLink whether or not the account exists.
+func (a AuthService) SendPasswordReset(ctx context.Context, email string) error {
+	user, err := a.findUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	go a.deliverLoginChallenge(context.WithoutCancel(ctx), user, model.LoginChallengePasswordReset)
+	return nil
+}
+
+// ResetPassword sets the password from a reset link and ends every session
+// of the account. Other reset links still open for it stop working.
+func (a AuthService) ResetPassword(ctx context.Context, req *model.ResetPasswordReq) error {
+	challenge, err := a.challengeRepo.WithContext(ctx).FindActiveByHash(model.LoginChallengePasswordReset, hashLoginToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrInvalidPasswordReset
+		}
+		return err
+	}
+
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{challenge.UserId}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrInvalidPasswordReset
+		}
+		return err
+	}
+
+	// checked before the token is spent, so the user can try another
+	// password with the same link
+	if err := a.policy.Validate(req.Password, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	used, err := a.challengeRepo.WithContext(ctx).Use(challenge.Id)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return model.ErrInvalidPasswordReset
+	}
+
+	passwordHash, err := library.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+	if err := a.repo.WithContext(ctx).UpdatePassword(challenge.UserId, passwordHash); err != nil {
+		return err
+	}
+	if err := a.challengeRepo.WithContext(ctx).UseAllByUser(challenge.UserId, model.LoginChallengePasswordReset); err != nil {
+		return err
+	}
+
+	return a.endSessions(ctx, challenge.UserId)
+}
+
+// ChangePassword replaces the password of the signed-in user once the
+// current one checks out. After DefaultPasswordMaxAttempts wrong current
+// passwords further attempts are refused until DefaultPasswordLockout has
+// passed. Every other session ends; the caller's session
+// gets a fresh token pair, bound to the same DPoP key, so it stays signed
+// in. The user is told about the change by email.
+func (a AuthService) ChangePassword(ctx context.Context, payload *token.Payload, req *model.ChangePasswordReq, device *model.DeviceInfo) (*model.TokenOutput, error) {
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{payload.UserId}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// counted before the check, like two-factor codes, so a stolen token
+	// can not be used to guess the password
+	claimed, err := a.repo.WithContext(ctx).ClaimPasswordAttempt(user.Id, constants.DefaultPasswordMaxAttempts, time.Now().Add(-constants.DefaultPasswordLockout))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, model.ErrTooManyPasswordAttempts
+	}
+	if !library.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		return nil, model.ErrInvalidCurrentPassword
+	}
+	if err := a.repo.WithContext(ctx).ResetPasswordAttempts(user.Id); err != nil {
+		return nil, err
+	}
+	if err := a.policy.Validate(req.NewPassword, user.Username, user.Email); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := library.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.repo.WithContext(ctx).UpdatePassword(user.Id, passwordHash); err != nil {
+		return nil, err
+	}
+
+	var result *model.TokenOutput
+	if payload.SessionID == "" {
+		// tokens from before sessions existed: end everything and open a
+		// session for this device
+		if err := a.endSessions(ctx, user.Id); err != nil {
+			return nil, err
+		}
+		result, err = a.GenerateToken(ctx, user, device)
+	} else {
+		if err := a.repo.WithContext(ctx).IncrementSessionVersion(ctx, user.Id); err != nil {
+			return nil, err
+		}
+		if err := a.sessionRepo.WithContext(ctx).RevokeOthersByUser(user.Id, payload.SessionID); err != nil {
+			return nil, err
+		}
+		result, err = a.issueTokens(ctx, user, payload.SessionID, uuid.NewString(), nil, device.DPoPThumbprint)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	go a.notifyPasswordChanged(context.WithoutCancel(ctx), user)
 	return result, nil
 }
 
+// notifyPasswordChanged runs after the response has been sent, so errors
+// are only logged.
+func (a AuthService) notifyPasswordChanged(ctx context.Context, user *model.User) {
+	err := a.mailer.Send(ctx, &model.MailMessage{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    fmt.Sprintf("The password of your account was changed at %s and every other session was signed out.\n\nIf you did not change it, reset your password right away.\n", time.Now().UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		log.Printf("Error in notifyPasswordChanged: user %d: %v", user.Id, err)
+	}
+}
+
+// findUserByEmail returns nil without an error when no active account has
+// the email.
+func (a AuthService) findUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.email = ? AND users.deleted_at IS NULL", Value: []any{email}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// deliverLoginChallenge creates a challenge of kind and emails it. It runs
+// after the response has been sent, so errors are only logged.
+func (a AuthService) deliverLoginChallenge(ctx context.Context, user *model.User, kind string) {
+	if err := a.sendLoginChallenge(ctx, user, kind); err != nil {
+		log.Printf("Error in deliverLoginChallenge: %s for user %d: %v", kind, user.Id, err)
+	}
+}
+
+func (a AuthService) sendLoginChallenge(ctx context.Context, user *model.User, kind string) error {
+	// at most DefaultLoginChallengeLimit per DefaultLoginChallengeWindow,
+	// so the endpoints can not be used to flood an inbox
+	sent, err := a.challengeRepo.WithContext(ctx).CountSince(user.Id, kind, time.Now().Add(-constants.DefaultLoginChallengeWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= int64(constants.DefaultLoginChallengeLimit) {
+		return errors.New("too many requests, not sending")
+	}
+
+	var secretHash string
+	var expiry time.Duration
+	msg := &model.MailMessage{To: user.Email}
+
+	switch kind {
+	case model.LoginChallengeMagicLink:
+		loginToken, err := newLinkToken()
+		if err != nil {
+			return err
+		}
+		link, err := tokenURL(a.env.Passwordless.MagicLinkURL, constants.DefaultMagicLinkURL, loginToken)
+		if err != nil {
+			return fmt.Errorf("invalid passwordless.magicLinkUrl: %w", err)
+		}
+
+		secretHash = hashLoginToken(loginToken)
+		expiry = library.MagicLinkExpiry()
+		msg.Subject = "Your sign-in link"
+		msg.Body = fmt.Sprintf("Open this link within %s to sign in:\n\n%s\n\nIf you did not ask to sign in, you can ignore this email.\n", expiry, link)
+	case model.LoginChallengeEmailOTP:
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return err
+		}
+		code := fmt.Sprintf("%06d", n.Int64())
+		secretHash, err = library.HashPassword(code)
+		if err != nil {
+			return fmt.Errorf("failed to hash code: %w", err)
+		}
+
+		expiry = library.EmailOTPExpiry()
+		msg.Subject = "Your sign-in code"
+		msg.Body = fmt.Sprintf("Your sign-in code is %s. It expires in %s.\n\nIf you did not ask to sign in, you can ignore this email.\n", code, expiry)
+	case model.LoginChallengeEmailVerification:
+		verifyToken, err := newLinkToken()
+		if err != nil {
+			return err
+		}
+		link, err := tokenURL(a.env.Registration.VerifyURL, constants.DefaultEmailVerifyURL, verifyToken)
+		if err != nil {
+			return fmt.Errorf("invalid registration.verifyUrl: %w", err)
+		}
+
+		secretHash = hashLoginToken(verifyToken)
+		expiry = library.EmailVerificationExpiry()
+		msg.Subject = "Verify your email address"
+		msg.Body = fmt.Sprintf("Open this link within %s to verify your email address:\n\n%s\n\nIf you did not create an account, you can ignore this email.\n", expiry, link)
+	case model.LoginChallengePasswordReset:
+		resetToken, err := newLinkToken()
+		if err != nil {
+			return err
+		}
+		link, err := tokenURL(a.env.PasswordReset.URL, constants.DefaultPasswordResetURL, resetToken)
+		if err != nil {
+			return fmt.Errorf("invalid passwordReset.url: %w", err)
+		}
+
+		secretHash = hashLoginToken(resetToken)
+		expiry = library.PasswordResetExpiry()
+		msg.Subject = "Reset your password"
+		msg.Body = fmt.Sprintf("Open this link within %s to choose a new password:\n\n%s\n\nIf you did not ask to reset your password, you can ignore this email; your password stays the same.\n", expiry, link)
+	default:
+		return fmt.Errorf("unknown login challenge kind %q", kind)
+	}
+
+	_, err = a.challengeRepo.WithContext(ctx).Create(&model.LoginChallenge{
+		UserId:     user.Id,
+		Kind:       kind,
+		SecretHash: secretHash,
+		ExpiresAt:  time.Now().Add(expiry),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store login challenge: %w", err)
+	}
+
+	return a.mailer.Send(ctx, msg)
+}
+
+// newLinkToken returns a random token to put in an emailed link.
+func newLinkToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashLoginToken(loginToken string) string {
+	sum := sha256.Sum256([]byte(loginToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenURL adds the token to the configured page, or to fallback when none
+// is configured, as ?token=.
+func tokenURL(base string, fallback string, loginToken string) (string, error) {
+	if base == "" {
+		base = fallback
+	}
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", loginToken)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// GenerateToken opens a new device session for user and issues its first
+// token pair. Like completeLogin it refuses unverified users, for first
+// factors such as passkeys that issue tokens directly.
+func (a AuthService) GenerateToken(ctx context.Context, user *model.User, device *model.DeviceInfo) (result *model.TokenOutput, err error) {
+	if err := a.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	session := &model.Session{
+		Id:     uuid.NewString(),
+		UserId: user.Id,
+	}
+	var dpopThumbprint string
+	if device != nil {
+		session.DeviceLabel = device.Label
+		session.IPAddress = device.IPAddress
+		session.UserAgent = device.UserAgent
+		dpopThumbprint = device.DPoPThumbprint
+	}
+	if _, err := a.sessionRepo.WithContext(ctx).Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return a.issueTokens(ctx, user, session.Id, uuid.NewString(), nil, dpopThumbprint)
+}
+
+// VerifyRefreshToken rotates the presented refresh token. The token is
+// consumed and a successor is issued in the same family; presenting a token
+// that was already consumed revokes the whole family. Verification fails
+// once the session is past its idle timeout or absolute lifetime, and a
+// successful refresh counts as activity.
 func (a AuthService) VerifyRefreshToken(ctx context.Context, req *model.RefreshTokenReq) (*model.TokenOutput, error) {
 	payload, err := a.tokenMaker.VerifyToken(ctx, req.RefreshToken)
 	if err != nil {
@@ -100,25 +662,262 @@ func (a AuthService) VerifyRefreshToken(ctx context.Context, req *model.RefreshT
 	if payload.TokenType != "refresh" {
 		return nil, fmt.Errorf("invalid token type: expected 'refresh', got '%s'", payload.TokenType)
 	}
+	if payload.IsImpersonated() {
+		return nil, model.ErrImpersonationDenied
+	}
+	// a DPoP-bound refresh token is only usable with a proof from its key
+	if payload.Confirmation != nil && payload.Confirmation.JKT != req.DPoPThumbprint {
+		return nil, token.ErrDPoPKeyMismatch
+	}
+	if req.AccessToken != "" {
+		if err := a.checkTokenPair(ctx, req.AccessToken, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := a.refreshRepo.WithContext(ctx).FindByJti(payload.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("refresh token not recognized")
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		return nil, model.ErrRefreshTokenRevoked
+	}
+
+	consumed, err := a.refreshRepo.WithContext(ctx).Consume(stored.Jti)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		if err := a.revokeFamily(ctx, stored.FamilyId, payload.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrRefreshTokenReused
+	}
+
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{payload.UserId}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the account was deleted: end the family instead of rotating it
+			if err := a.revokeFamily(ctx, stored.FamilyId, payload.SessionID); err != nil {
+				return nil, err
+			}
+			return nil, model.ErrRefreshTokenRevoked
+		}
+		return nil, err
+	}
+
+	if payload.SessionID != "" {
+		if err := a.sessionRepo.WithContext(ctx).Touch(payload.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.issueTokens(ctx, user, payload.SessionID, stored.FamilyId, &stored.Jti, req.DPoPThumbprint)
+}
+
+// revokeFamily revokes every refresh token of familyId and the session it
+// belongs to.
+func (a AuthService) revokeFamily(ctx context.Context, familyId string, sessionId string) error {
+	if err := a.refreshRepo.WithContext(ctx).RevokeFamily(familyId); err != nil {
+		return err
+	}
+	if sessionId == "" {
+		return nil
+	}
+	return a.sessionRepo.WithContext(ctx).Revoke(sessionId)
+}
+
+// checkTokenPair makes sure accessToken, expired or not, was issued to the
+// same user and session as the refresh token.
+func (a AuthService) checkTokenPair(ctx context.Context, accessToken string, refresh *token.Payload) error {
+	access, err := a.tokenMaker.DecodeToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("invalid access token: %w", err)
+	}
+	if access.TokenType != "access" {
+		return fmt.Errorf("invalid token type: expected 'access', got '%s'", access.TokenType)
+	}
+	if access.UserId != refresh.UserId || access.SessionID != refresh.SessionID {
+		return errors.New("access token does not belong to this refresh token")
+	}
+	return nil
+}
+
+// issueTokens mints an access/refresh pair bound to sessionId, and to the
+// DPoP key dpopThumbprint when set, and records the refresh token as the
+// newest member of familyId.
+func (a AuthService) issueTokens(ctx context.Context, user *model.User, sessionId string, familyId string, parentJti *string, dpopThumbprint string) (*model.TokenOutput, error) {
+	accessTokenExpiry := library.AccessTokenExpiry()
+	refreshTokenExpiry := library.RefreshTokenExpiry()
+
+	params, err := a.tokenParams(ctx, user, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if dpopThumbprint != "" {
+		params.Confirmation = &token.Confirmation{JKT: dpopThumbprint}
+	}
 
-	newAccessToken, err := a.tokenMaker.CreateToken(
-		payload.UserId,
-		payload.Email,
-		payload.RoleId,
-		library.AccessTokenExpiry(),
-	)
+	accessToken, _, err := a.tokenMaker.CreateToken(ctx, params, accessTokenExpiry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create access token: %w", err)
 	}
 
+	refreshToken, refreshPayload, err := a.tokenMaker.CreateRefreshToken(ctx, params, refreshTokenExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	_, err = a.refreshRepo.WithContext(ctx).Create(&model.RefreshToken{
+		Jti:       refreshPayload.ID,
+		FamilyId:  familyId,
+		ParentJti: parentJti,
+		UserId:    user.Id,
+		ExpiresAt: refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	bearerType := "Bearer"
+	if dpopThumbprint != "" {
+		bearerType = "DPoP"
+	}
+
 	return &model.TokenOutput{
-		AccessToken:  newAccessToken,
-		RefreshToken: req.RefreshToken,
-		ExpiredToken: time.Now().Add(library.AccessTokenExpiry()).Format(time.RFC3339),
-		UserId:       int(payload.UserId),
+		BearerType:       bearerType,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiredToken:     time.Now().Add(accessTokenExpiry).Format(time.RFC3339),
+		UserId:           int(user.Id),
+		RequireTwoFactor: false,
 	}, nil
 }
 
+// tokenParams loads the role and its permissions so every token carries
+// the scopes the user holds at the time it is minted.
+func (a AuthService) tokenParams(ctx context.Context, user *model.User, sessionId string) (*token.TokenParams, error) {
+	params := &token.TokenParams{
+		UserID:    user.Id,
+		Email:     user.Email,
+		RoleId:    strconv.FormatInt(int64(user.RoleId), 10),
+		SessionID: sessionId,
+	}
+
+	role, err := a.roleRepo.WithContext(ctx).FindByID(user.RoleId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load role: %w", err)
+	}
+	if role != nil {
+		params.Role = role.Name
+	}
+
+	// registration.unverifiedLogin limited: signed in, but holding no
+	// scopes until the email address is verified
+	if !user.EmailVerified && a.env.Registration.UnverifiedLogin == constants.UnverifiedLoginLimited {
+		return params, nil
+	}
+
+	params.Scopes, err = a.roleRepo.WithContext(ctx).GetPermissions(user.RoleId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+
+	return params, nil
+}
+
+// Logout revokes only the session the token belongs to. Tokens issued before
+// sessions existed carry no sid and fall back to logging out everywhere.
 func (a AuthService) Logout(ctx context.Context, payload *token.Payload) error {
-	return a.repo.WithContext(ctx).IncrementSessionVersion(ctx, payload.UserId)
+	if payload.SessionID == "" {
+		return a.LogoutAll(ctx, payload)
+	}
+	return a.sessionRepo.WithContext(ctx).Revoke(payload.SessionID)
+}
+
+func (a AuthService) LogoutAll(ctx context.Context, payload *token.Payload) error {
+	return a.endSessions(ctx, payload.UserId)
+}
+
+// endSessions invalidates every token of the user and revokes its sessions.
+func (a AuthService) endSessions(ctx context.Context, userId int64) error {
+	if err := a.repo.WithContext(ctx).IncrementSessionVersion(ctx, userId); err != nil {
+		return err
+	}
+	return a.sessionRepo.WithContext(ctx).RevokeAllByUser(userId)
+}
+
+// Impersonate lets actor act as userId through a short-lived access token
+// that names actor in its act claim. No refresh token is issued, and the
+// token gets its own session so it shows up next to the user's devices and
+// dies with logout everywhere.
+func (a AuthService) Impersonate(ctx context.Context, actor *token.Payload, userId int64, device *model.DeviceInfo) (*model.TokenOutput, error) {
+	if actor.IsImpersonated() {
+		return nil, model.ErrImpersonationDenied
+	}
+	if actor.UserId == userId {
+		return nil, model.ErrImpersonateSelf
+	}
+
+	user, err := a.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{userId}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	actorId := strconv.FormatInt(actor.UserId, 10)
+	session := &model.Session{
+		Id:          uuid.NewString(),
+		UserId:      user.Id,
+		DeviceLabel: "impersonated by user " + actorId,
+	}
+	if device != nil {
+		session.IPAddress = device.IPAddress
+		session.UserAgent = device.UserAgent
+	}
+	if _, err := a.sessionRepo.WithContext(ctx).Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	params, err := a.tokenParams(ctx, user, session.Id)
+	if err != nil {
+		return nil, err
+	}
+	params.Actor = &token.Actor{Subject: actorId}
+
+	expiry := library.ImpersonationTokenExpiry()
+	accessToken, payload, err := a.tokenMaker.CreateToken(ctx, params, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	audit := &model.AuditLog{
+		Action:    model.AuditImpersonationStarted,
+		ActorId:   &actor.UserId,
+		SubjectId: &user.Id,
+		Detail:    "jti " + payload.ID,
+	}
+	if device != nil {
+		audit.IPAddress = device.IPAddress
+		audit.UserAgent = device.UserAgent
+	}
+	if _, err := a.auditRepo.WithContext(ctx).Create(audit); err != nil {
+		return nil, fmt.Errorf("failed to record audit log: %w", err)
+	}
+
+	return &model.TokenOutput{
+		BearerType:   "Bearer",
+		AccessToken:  accessToken,
+		ExpiredToken: payload.ExpiredAt.Format(time.RFC3339),
+		UserId:       int(user.Id),
+	}, nil
 }
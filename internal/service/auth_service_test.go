@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+// memoryRefreshTokens keeps refresh token families the way the
+// refresh_tokens table does.
+type memoryRefreshTokens struct {
+	model.RefreshTokenMethodRepository
+	tokens map[string]*model.RefreshToken
+}
+
+func (m *memoryRefreshTokens) WithContext(ctx context.Context) model.RefreshTokenMethodRepository {
+	return m
+}
+
+func (m *memoryRefreshTokens) Create(data *model.RefreshToken) (*model.RefreshToken, error) {
+	m.tokens[data.Jti] = data
+	return data, nil
+}
+
+func (m *memoryRefreshTokens) FindByJti(jti string) (*model.RefreshToken, error) {
+	stored, ok := m.tokens[jti]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return stored, nil
+}
+
+func (m *memoryRefreshTokens) Consume(jti string) (bool, error) {
+	stored, ok := m.tokens[jti]
+	if !ok || stored.Consumed || stored.RevokedAt != nil {
+		return false, nil
+	}
+	stored.Consumed = true
+	return true, nil
+}
+
+func (m *memoryRefreshTokens) RevokeFamily(familyId string) error {
+	now := time.Now()
+	for _, stored := range m.tokens {
+		if stored.FamilyId == familyId && stored.RevokedAt == nil {
+			stored.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type memoryRoles struct {
+	model.RoleMethodRepository
+}
+
+func (m *memoryRoles) WithContext(ctx context.Context) model.RoleMethodRepository {
+	return m
+}
+
+func (m *memoryRoles) FindByID(id int64) (*model.Role, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryRoles) GetPermissions(roleId int64) ([]string, error) {
+	return []string{"users:read"}, nil
+}
+
+type memoryChallenges struct {
+	model.LoginChallengeMethodRepository
+	challenges []*model.LoginChallenge
+}
+
+func (m *memoryChallenges) WithContext(ctx context.Context) model.LoginChallengeMethodRepository {
+	return m
+}
+
+func (m *memoryChallenges) FindActiveByHash(kind string, secretHash string) (*model.LoginChallenge, error) {
+	for _, challenge := range m.challenges {
+		if challenge.Kind == kind && challenge.SecretHash == secretHash && challenge.UsedAt == nil {
+			return challenge, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryChallenges) Use(id int64) (bool, error) {
+	for _, challenge := range m.challenges {
+		if challenge.Id == id && challenge.UsedAt == nil {
+			now := time.Now()
+			challenge.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryRevocations struct {
+	model.RevokedTokenMethodRepository
+	revoked map[string]bool
+}
+
+func (m *memoryRevocations) WithContext(ctx context.Context) model.RevokedTokenMethodRepository {
+	return m
+}
+
+func (m *memoryRevocations) Create(data *model.RevokedToken) (*model.RevokedToken, error) {
+	m.revoked[data.Jti] = true
+	return data, nil
+}
+
+func (m *memoryRevocations) Claim(data *model.RevokedToken) (bool, error) {
+	if m.revoked[data.Jti] {
+		return false, nil
+	}
+	m.revoked[data.Jti] = true
+	return true, nil
+}
+
+func (m *memoryRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return m.revoked[jti], nil
+}
+
+// fixedTwoFactor has two-factor authentication on for every user and
+// accepts a single code.
+type fixedTwoFactor struct {
+	model.TwoFactorMethodService
+	code string
+}
+
+func (f *fixedTwoFactor) IsEnabled(ctx context.Context, userId int64) (bool, error) {
+	return f.code != "", nil
+}
+
+func (f *fixedTwoFactor) Check(ctx context.Context, userId int64, code string) error {
+	if code != f.code {
+		return model.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+type authFixture struct {
+	service    model.AuthMethodService
+	users      *memoryUsers
+	refresh    *memoryRefreshTokens
+	sessions   *memorySessions
+	challenges *memoryChallenges
+	maker      token.Maker
+}
+
+func newAuthFixture(t *testing.T, twoFactor model.TwoFactorMethodService, users ...*model.User) *authFixture {
+	t.Helper()
+	f := &authFixture{
+		users:      newMemoryUsers(users...),
+		refresh:    &memoryRefreshTokens{tokens: make(map[string]*model.RefreshToken)},
+		sessions:   &memorySessions{},
+		challenges: &memoryChallenges{},
+	}
+	revocations := &memoryRevocations{revoked: make(map[string]bool)}
+	f.maker = newTestMaker(t, token.Options{Stores: token.Stores{Users: f.users, Sessions: f.sessions, Revocations: revocations}})
+	if twoFactor == nil {
+		twoFactor = &fixedTwoFactor{}
+	}
+	f.service = NewAuthService(f.users, f.refresh, f.sessions, &memoryRoles{}, nil,
+		f.challenges, revocations, twoFactor, nil, nil, library.Env{}, f.maker)
+	return f
+}
+
+func TestMagicLinkVerifiesEmail(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{Id: 7, Email: "bob@example.com"}
+	f := newAuthFixture(t, nil, user)
+	f.challenges.challenges = append(f.challenges.challenges, &model.LoginChallenge{
+		Id:         1,
+		UserId:     7,
+		Kind:       model.LoginChallengeMagicLink,
+		SecretHash: hashLoginToken("link-token"),
+		ExpiresAt:  time.Now().Add(time.Minute),
+	})
+
+	result, err := f.service.VerifyMagicLink(ctx, &model.MagicLinkVerifyReq{Token: "link-token"}, &model.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("VerifyMagicLink: %v", err)
+	}
+	if result.AccessToken == "" {
+		t.Fatal("VerifyMagicLink issued no access token")
+	}
+	if !user.EmailVerified {
+		t.Fatal("a used magic link did not verify the email address")
+	}
+}
+
+func TestUnverifiedUserGetsNoTokens(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{Id: 7, Email: "bob@example.com"}
+	f := newAuthFixture(t, nil, user)
+
+	// passkeys and other first factors that issue tokens directly
+	if _, err := f.service.GenerateToken(ctx, user, &model.DeviceInfo{}); !errors.Is(err, model.ErrEmailNotVerified) {
+		t.Fatalf("GenerateToken: err = %v, want %v", err, model.ErrEmailNotVerified)
+	}
+}
+
+func TestMFATokenIsUsedUp(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{Id: 7, Email: "bob@example.com", EmailVerified: true}
+	f := newAuthFixture(t, &fixedTwoFactor{code: "123456"}, user)
+
+	mfaToken, _, err := f.maker.CreateMFAPendingToken(ctx, &token.TokenParams{UserID: 7, Email: user.Email}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a wrong code does not use the token up
+	req := &model.TwoFactorVerifyReq{MFAToken: mfaToken, Code: "000000"}
+	if _, err := f.service.VerifyTwoFactor(ctx, req, &model.DeviceInfo{}); !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		t.Fatalf("VerifyTwoFactor with a wrong code: err = %v, want %v", err, model.ErrInvalidTwoFactorCode)
+	}
+
+	req.Code = "123456"
+	if _, err := f.service.VerifyTwoFactor(ctx, req, &model.DeviceInfo{}); err != nil {
+		t.Fatalf("VerifyTwoFactor: %v", err)
+	}
+	if _, err := f.service.VerifyTwoFactor(ctx, req, &model.DeviceInfo{}); err == nil {
+		t.Fatal("VerifyTwoFactor accepted the same mfa token twice")
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{Id: 7, Email: "bob@example.com", EmailVerified: true}
+	f := newAuthFixture(t, nil, user)
+
+	login, err := f.service.GenerateToken(ctx, user, &model.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	rotated, err := f.service.VerifyRefreshToken(ctx, &model.RefreshTokenReq{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("VerifyRefreshToken: %v", err)
+	}
+
+	// a copy of the first refresh token shows up again
+	_, err = f.service.VerifyRefreshToken(ctx, &model.RefreshTokenReq{RefreshToken: login.RefreshToken})
+	if !errors.Is(err, model.ErrRefreshTokenReused) {
+		t.Fatalf("reused refresh token: err = %v, want %v", err, model.ErrRefreshTokenReused)
+	}
+
+	// which ends the family and the session, legitimate successor included
+	_, err = f.service.VerifyRefreshToken(ctx, &model.RefreshTokenReq{RefreshToken: rotated.RefreshToken})
+	if err == nil {
+		t.Fatal("the successor of a reused refresh token still rotates")
+	}
+	if _, err := f.maker.VerifyToken(ctx, rotated.AccessToken); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Fatalf("access token of the revoked session: err = %v, want %v", err, token.ErrTokenRevoked)
+	}
+}
+
+func TestChangePasswordLocksAfterTooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	passwordHash, err := library.HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Id: 7, Email: "bob@example.com", EmailVerified: true, Password: passwordHash}
+	f := newAuthFixture(t, nil, user)
+	payload := &token.Payload{UserId: 7, SessionID: "session"}
+
+	wrong := &model.ChangePasswordReq{CurrentPassword: "guess", NewPassword: "a new long password"}
+	for i := 0; i < constants.DefaultPasswordMaxAttempts; i++ {
+		if _, err := f.service.ChangePassword(ctx, payload, wrong, &model.DeviceInfo{}); !errors.Is(err, model.ErrInvalidCurrentPassword) {
+			t.Fatalf("attempt %d: err = %v, want %v", i+1, err, model.ErrInvalidCurrentPassword)
+		}
+	}
+
+	// locked: even the right password is not checked any more
+	right := &model.ChangePasswordReq{CurrentPassword: "correct horse battery staple", NewPassword: "a new long password"}
+	if _, err := f.service.ChangePassword(ctx, payload, right, &model.DeviceInfo{}); !errors.Is(err, model.ErrTooManyPasswordAttempts) {
+		t.Fatalf("attempt after the limit: err = %v, want %v", err, model.ErrTooManyPasswordAttempts)
+	}
+	if user.Password != passwordHash {
+		t.Fatal("the password was changed while locked")
+	}
+}
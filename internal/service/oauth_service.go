@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidClient = errors.New("invalid client credentials")
+
+type OAuthService struct {
+	clientRepo  model.OAuthClientMethodRepository
+	revokedRepo model.RevokedTokenMethodRepository
+	refreshRepo model.RefreshTokenMethodRepository
+	sessionRepo model.SessionMethodRepository
+	env         library.Env
+	tokenMaker  token.Maker
+}
+
+func NewOAuthService(
+	clientRepo model.OAuthClientMethodRepository,
+	revokedRepo model.RevokedTokenMethodRepository,
+	refreshRepo model.RefreshTokenMethodRepository,
+	sessionRepo model.SessionMethodRepository,
+	env library.Env,
+	tokenMaker token.Maker,
+) model.OAuthMethodService {
+	return &OAuthService{
+		clientRepo:  clientRepo,
+		revokedRepo: revokedRepo,
+		refreshRepo: refreshRepo,
+		sessionRepo: sessionRepo,
+		env:         env,
+		tokenMaker:  tokenMaker,
+	}
+}
+
+func (o OAuthService) AuthenticateClient(ctx context.Context, clientId string, clientSecret string) (*model.OAuthClient, error) {
+	client, err := o.clientRepo.WithContext(ctx).FindByClientId(clientId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	if !library.CheckPasswordHash(clientSecret, client.ClientSecret) {
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// Introspect reports whether a token is currently usable (RFC 7662). Any
+// verification failure, including revocation, is an inactive token rather
+// than an error. Only access and refresh tokens can be active; an
+// mfa_pending token proves just the password. Tokens for an audience the
+// client does not handle are reported inactive as well.
+func (o OAuthService) Introspect(ctx context.Context, client *model.OAuthClient, req *model.IntrospectionReq) (*model.IntrospectionResponse, error) {
+	payload, err := o.tokenMaker.IntrospectToken(ctx, req.Token)
+	if err != nil {
+		return &model.IntrospectionResponse{Active: false}, nil
+	}
+	if payload.TokenType != "access" && payload.TokenType != "refresh" {
+		return &model.IntrospectionResponse{Active: false}, nil
+	}
+	if !client.HandlesAudience(payload.Audience) {
+		return &model.IntrospectionResponse{Active: false}, nil
+	}
+
+	return &model.IntrospectionResponse{
+		Active:    true,
+		Sub:       strconv.FormatInt(payload.UserId, 10),
+		Exp:       payload.ExpiredAt.Unix(),
+		Iat:       payload.IssuedAt.Unix(),
+		Nbf:       payload.NotBefore.Unix(),
+		Iss:       payload.Issuer,
+		Aud:       payload.Audience,
+		Jti:       payload.ID,
+		TokenType: payload.TokenType + "_token",
+		Scope:     strings.Join(payload.Scopes, " "),
+		Cnf:       payload.Confirmation,
+	}, nil
+}
+
+// Revoke invalidates a single token (RFC 7009). Revoking a refresh token
+// also ends its family and device session, and with it the access tokens
+// issued from the same login. Unknown or already invalid tokens are not an
+// error, so callers cannot probe which tokens exist. A client may only
+// revoke tokens for an audience it was granted (RFC 7009 section 2.1).
+func (o OAuthService) Revoke(ctx context.Context, client *model.OAuthClient, req *model.RevocationReq) error {
+	payload, err := o.tokenMaker.IntrospectToken(ctx, req.Token)
+	if err != nil {
+		return nil
+	}
+	if !client.HandlesAudience(payload.Audience) {
+		return &model.OAuthError{Code: "unauthorized_client", Description: "token audience is not granted to this client"}
+	}
+
+	_, err = o.revokedRepo.WithContext(ctx).Create(&model.RevokedToken{
+		Jti:       payload.ID,
+		UserId:    payload.UserId,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if payload.TokenType != "refresh" {
+		return nil
+	}
+
+	stored, err := o.refreshRepo.WithContext(ctx).FindByJti(payload.ID)
+	if err == nil {
+		if err := o.refreshRepo.WithContext(ctx).RevokeFamily(stored.FamilyId); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if payload.SessionID != "" {
+		return o.sessionRepo.WithContext(ctx).Revoke(payload.SessionID)
+	}
+	return nil
+}
+
+// Exchange trades a user's access token for one meant for another service
+// (RFC 8693). The new token keeps the user as subject and session, narrows
+// the audience and scopes, names the client in its act claim and never
+// outlives the subject token. Clients can only exchange for an audience
+// they were granted. A DPoP-bound subject token yields a token bound to
+// the same key. Tokens that already name an actor, exchanged or
+// impersonated, are refused so act never nests.
+func (o OAuthService) Exchange(ctx context.Context, client *model.OAuthClient, req *model.TokenExchangeReq) (*model.TokenExchangeResponse, error) {
+	if req.GrantType != model.GrantTypeTokenExchange {
+		return nil, &model.OAuthError{Code: "unsupported_grant_type"}
+	}
+	if req.SubjectTokenType != model.TokenTypeAccessToken {
+		return nil, &model.OAuthError{Code: "invalid_request", Description: "subject_token_type must be an access token"}
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != model.TokenTypeAccessToken {
+		return nil, &model.OAuthError{Code: "invalid_request", Description: "only access tokens can be requested"}
+	}
+	if req.Audience == "" || !slices.Contains(o.env.Token.ExchangeAudiences, req.Audience) {
+		return nil, &model.OAuthError{Code: "invalid_target", Description: "audience is not an exchange audience"}
+	}
+	if !client.HandlesAudience(req.Audience) {
+		return nil, &model.OAuthError{Code: "unauthorized_client", Description: "audience is not granted to this client"}
+	}
+
+	payload, err := o.tokenMaker.VerifyToken(ctx, req.SubjectToken)
+	if err != nil {
+		return nil, &model.OAuthError{Code: "invalid_grant", Description: err.Error()}
+	}
+	if payload.TokenType != "access" {
+		return nil, &model.OAuthError{Code: "invalid_grant", Description: "subject token is not an access token"}
+	}
+	if payload.Actor != nil {
+		return nil, &model.OAuthError{Code: "invalid_grant", Description: "subject token was already issued to an actor"}
+	}
+
+	scopes := payload.Scopes
+	if req.Scope != "" {
+		scopes = strings.Fields(req.Scope)
+		for _, scope := range scopes {
+			if !payload.HasScope(scope) {
+				return nil, &model.OAuthError{Code: "invalid_scope", Description: "subject token does not hold " + scope}
+			}
+		}
+	}
+
+	params := &token.TokenParams{
+		UserID:    payload.UserId,
+		Email:     payload.Email,
+		RoleId:    payload.RoleId,
+		Role:      payload.Role,
+		Scopes:    scopes,
+		SessionID: payload.SessionID,
+		Actor:     &token.Actor{Subject: client.ClientId},
+		Audience:  req.Audience,
+		// no proof is presented here, so a DPoP-bound subject token stays
+		// bound: the new token is only usable with the same key
+		Confirmation: payload.Confirmation,
+	}
+
+	duration := min(library.AccessTokenExpiry(), time.Until(payload.ExpiredAt))
+	accessToken, issued, err := o.tokenMaker.CreateToken(ctx, params, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenType := "Bearer"
+	if issued.Confirmation != nil {
+		tokenType = "DPoP"
+	}
+
+	return &model.TokenExchangeResponse{
+		AccessToken:     accessToken,
+		IssuedTokenType: model.TokenTypeAccessToken,
+		TokenType:       tokenType,
+		ExpiresIn:       int64(time.Until(issued.ExpiredAt).Seconds()),
+		Scope:           strings.Join(issued.Scopes, " "),
+	}, nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+type oauthFixture struct {
+	service  model.OAuthMethodService
+	maker    token.Maker
+	refresh  *memoryRefreshTokens
+	sessions *memorySessions
+}
+
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+	var env library.Env
+	env.Token.Audience = "auth"
+	env.Token.ExchangeAudiences = []string{"orders", "billing"}
+
+	f := &oauthFixture{
+		refresh:  &memoryRefreshTokens{tokens: make(map[string]*model.RefreshToken)},
+		sessions: &memorySessions{},
+	}
+	revocations := &memoryRevocations{revoked: make(map[string]bool)}
+	f.maker = newTestMaker(t, token.Options{
+		Audience:          env.Token.Audience,
+		ExchangeAudiences: env.Token.ExchangeAudiences,
+		Stores:            token.Stores{Sessions: f.sessions, Revocations: revocations},
+	})
+	f.service = NewOAuthService(nil, revocations, f.refresh, f.sessions, env, f.maker)
+	return f
+}
+
+func subjectToken(t *testing.T, maker token.Maker, params *token.TokenParams) string {
+	t.Helper()
+	accessToken, _, err := maker.CreateToken(context.Background(), params, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accessToken
+}
+
+func exchangeReq(subjectToken string, audience string) *model.TokenExchangeReq {
+	return &model.TokenExchangeReq{
+		GrantType:        model.GrantTypeTokenExchange,
+		SubjectToken:     subjectToken,
+		SubjectTokenType: model.TokenTypeAccessToken,
+		Audience:         audience,
+	}
+}
+
+func oauthErrorCode(err error) string {
+	var oauthErr *model.OAuthError
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Code
+	}
+	return ""
+}
+
+func TestExchangeOnlyForGrantedAudiences(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t)
+	service, maker := f.service, f.maker
+	client := &model.OAuthClient{ClientId: "orders-client", Audiences: "orders"}
+	subject := subjectToken(t, maker, &token.TokenParams{UserID: 7, Scopes: []string{"orders:read"}})
+
+	result, err := service.Exchange(ctx, client, exchangeReq(subject, "orders"))
+	if err != nil {
+		t.Fatalf("Exchange for a granted audience: %v", err)
+	}
+	exchanged, err := maker.IntrospectToken(ctx, result.AccessToken)
+	if err != nil {
+		t.Fatalf("IntrospectToken: %v", err)
+	}
+	if exchanged.Audience != "orders" || exchanged.Actor == nil || exchanged.Actor.Subject != "orders-client" {
+		t.Fatalf("exchanged token aud = %q act = %+v, want orders by orders-client", exchanged.Audience, exchanged.Actor)
+	}
+
+	_, err = service.Exchange(ctx, client, exchangeReq(subject, "billing"))
+	if code := oauthErrorCode(err); code != "unauthorized_client" {
+		t.Fatalf("Exchange for an ungranted audience: err = %v, want unauthorized_client", err)
+	}
+}
+
+func TestExchangeKeepsDPoPBinding(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t)
+	service, maker := f.service, f.maker
+	client := &model.OAuthClient{ClientId: "orders-client", Audiences: "orders"}
+	subject := subjectToken(t, maker, &token.TokenParams{
+		UserID:       7,
+		Confirmation: &token.Confirmation{JKT: "thumbprint"},
+	})
+
+	result, err := service.Exchange(ctx, client, exchangeReq(subject, "orders"))
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if result.TokenType != "DPoP" {
+		t.Fatalf("token_type = %q, want DPoP", result.TokenType)
+	}
+	exchanged, err := maker.IntrospectToken(ctx, result.AccessToken)
+	if err != nil {
+		t.Fatalf("IntrospectToken: %v", err)
+	}
+	if exchanged.Confirmation == nil || exchanged.Confirmation.JKT != "thumbprint" {
+		t.Fatalf("exchanged cnf = %+v, want the subject token's key", exchanged.Confirmation)
+	}
+}
+
+func TestExchangeRefusesTokensWithAnActor(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t)
+	service, maker := f.service, f.maker
+	client := &model.OAuthClient{ClientId: "orders-client", Audiences: "orders"}
+
+	impersonated := subjectToken(t, maker, &token.TokenParams{UserID: 7, Actor: &token.Actor{Subject: "admin"}})
+	_, err := service.Exchange(ctx, client, exchangeReq(impersonated, "orders"))
+	if code := oauthErrorCode(err); code != "invalid_grant" {
+		t.Fatalf("Exchange of an impersonated token: err = %v, want invalid_grant", err)
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t)
+	client := &model.OAuthClient{ClientId: "gateway", Audiences: "auth"}
+	params := &token.TokenParams{UserID: 7, Scopes: []string{"users:read"}}
+
+	result, err := f.service.Introspect(ctx, client, &model.IntrospectionReq{Token: subjectToken(t, f.maker, params)})
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	if !result.Active || result.Sub != "7" || result.Scope != "users:read" || result.TokenType != "access_token" {
+		t.Fatalf("Introspect = %+v, want an active access token of user 7", result)
+	}
+
+	mfaToken, _, err := f.maker.CreateMFAPendingToken(ctx, params, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &model.OAuthClient{ClientId: "orders-client", Audiences: "orders"}
+	inactive := map[string]struct {
+		client *model.OAuthClient
+		token  string
+	}{
+		"malformed token":     {client, "v4.local.garbage"},
+		"mfa_pending token":   {client, mfaToken},
+		"audience of another": {other, subjectToken(t, f.maker, params)},
+	}
+	for name, tt := range inactive {
+		result, err := f.service.Introspect(ctx, tt.client, &model.IntrospectionReq{Token: tt.token})
+		if err != nil {
+			t.Fatalf("Introspect(%s): %v", name, err)
+		}
+		if result.Active {
+			t.Fatalf("Introspect(%s) reported an active token", name)
+		}
+	}
+}
+
+func TestRevokeRefreshTokenEndsSession(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t)
+	client := &model.OAuthClient{ClientId: "gateway", Audiences: "auth"}
+
+	f.sessions.sessions = append(f.sessions.sessions, &model.Session{Id: "session", UserId: 7})
+	params := &token.TokenParams{UserID: 7, SessionID: "session"}
+	accessToken := subjectToken(t, f.maker, params)
+	refreshToken, refreshPayload, err := f.maker.CreateRefreshToken(ctx, params, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.refresh.tokens[refreshPayload.ID] = &model.RefreshToken{Jti: refreshPayload.ID, FamilyId: "family", UserId: 7}
+
+	// unknown tokens are not an error
+	if err := f.service.Revoke(ctx, client, &model.RevocationReq{Token: "v4.local.garbage"}); err != nil {
+		t.Fatalf("Revoke of an unknown token: %v", err)
+	}
+	// nor can a client revoke tokens of an audience it was not granted
+	other := &model.OAuthClient{ClientId: "orders-client", Audiences: "orders"}
+	err = f.service.Revoke(ctx, other, &model.RevocationReq{Token: refreshToken})
+	if code := oauthErrorCode(err); code != "unauthorized_client" {
+		t.Fatalf("Revoke for another audience: err = %v, want unauthorized_client", err)
+	}
+
+	if err := f.service.Revoke(ctx, client, &model.RevocationReq{Token: refreshToken}); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	for name, revoked := range map[string]string{"refresh": refreshToken, "access": accessToken} {
+		result, err := f.service.Introspect(ctx, client, &model.IntrospectionReq{Token: revoked})
+		if err != nil {
+			t.Fatalf("Introspect(%s): %v", name, err)
+		}
+		if result.Active {
+			t.Fatalf("%s token of the revoked session is still active", name)
+		}
+	}
+	if f.refresh.tokens[refreshPayload.ID].RevokedAt == nil {
+		t.Fatal("the refresh token family was not revoked")
+	}
+}
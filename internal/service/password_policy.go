@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+// minUserInfoLength keeps short usernames such as "al" from ruling out
+// every password that happens to contain them.
+const minUserInfoLength = 3
+
+type PasswordPolicy struct {
+	minLength        int
+	requireUpper     bool
+	requireLower     bool
+	requireDigit     bool
+	requireSymbol    bool
+	maxRepeats       int
+	disallowUserInfo bool
+	breached         map[string]struct{}
+}
+
+// NewPasswordPolicy builds the policy configured under passwordPolicy and
+// loads the breached password list into memory when one is set.
+func NewPasswordPolicy(env library.Env) (model.PasswordPolicy, error) {
+	cfg := env.PasswordPolicy
+	policy := &PasswordPolicy{
+		minLength:        cfg.MinLength,
+		requireUpper:     cfg.RequireUpper,
+		requireLower:     cfg.RequireLower,
+		requireDigit:     cfg.RequireDigit,
+		requireSymbol:    cfg.RequireSymbol,
+		maxRepeats:       cfg.MaxRepeats,
+		disallowUserInfo: cfg.DisallowUserInfo,
+	}
+	if policy.minLength <= 0 {
+		policy.minLength = constants.DefaultPasswordMinLength
+	}
+
+	if cfg.BreachedListFile != "" {
+		breached, err := loadBreachedList(cfg.BreachedListFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load passwordPolicy.breachedListFile: %w", err)
+		}
+		policy.breached = breached
+	}
+
+	return policy, nil
+}
+
+// loadBreachedList reads one password per line. Blank lines and lines
+// starting with # are skipped.
+func loadBreachedList(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[line] = struct{}{}
+	}
+	return breached, scanner.Err()
+}
+
+func (p *PasswordPolicy) Validate(password string, username string, email string) error {
+	var violations []model.PasswordViolation
+	violate := func(rule string, format string, args ...any) {
+		violations = append(violations, model.PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.minLength {
+		violate(model.PasswordRuleMinLength, "must be at least %d characters long", p.minLength)
+	}
+	if len(password) > constants.PasswordMaxBytes {
+		violate(model.PasswordRuleMaxLength, "must be at most %d bytes long", constants.PasswordMaxBytes)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	var longestRun, run int
+	var previous rune
+	for i, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+
+		if i > 0 && r == previous {
+			run++
+		} else {
+			run = 1
+		}
+		longestRun = max(longestRun, run)
+		previous = r
+	}
+
+	if p.requireUpper && !hasUpper {
+		violate(model.PasswordRuleUpper, "must contain an uppercase letter")
+	}
+	if p.requireLower && !hasLower {
+		violate(model.PasswordRuleLower, "must contain a lowercase letter")
+	}
+	if p.requireDigit && !hasDigit {
+		violate(model.PasswordRuleDigit, "must contain a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		violate(model.PasswordRuleSymbol, "must contain a symbol")
+	}
+	if p.maxRepeats > 0 && longestRun > p.maxRepeats {
+		violate(model.PasswordRuleMaxRepeats, "must not repeat a character more than %d times in a row", p.maxRepeats)
+	}
+
+	if p.disallowUserInfo && containsUserInfo(password, username, email) {
+		violate(model.PasswordRuleUserInfo, "must not contain your username or email address")
+	}
+
+	if _, ok := p.breached[password]; ok {
+		violate(model.PasswordRuleBreached, "appears in a list of breached passwords")
+	}
+
+	if len(violations) > 0 {
+		return &model.PasswordPolicyError{
+			Message:    "password does not meet the password policy",
+			Violations: violations,
+		}
+	}
+	return nil
+}
+
+// containsUserInfo reports, ignoring case, whether password contains the
+// username, the email address or the part of it before the @.
+func containsUserInfo(password string, username string, email string) bool {
+	password = strings.ToLower(password)
+	localPart, _, _ := strings.Cut(email, "@")
+	for _, info := range []string{username, email, localPart} {
+		info = strings.ToLower(info)
+		if utf8.RuneCountInString(info) >= minUserInfoLength && strings.Contains(password, info) {
+			return true
+		}
+	}
+	return false
+}
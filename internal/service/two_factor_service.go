@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+// totpOpts are the RFC 6238 defaults every authenticator app supports.
+var totpOpts = totp.ValidateOpts{
+	Period:    30,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// recoveryCodeAlphabet has 32 symbols so every random byte maps onto it
+// without bias.
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+type TwoFactorService struct {
+	repo         model.UserTOTPMethodRepository
+	recoveryRepo model.RecoveryCodeMethodRepository
+	env          library.Env
+}
+
+func NewTwoFactorService(repo model.UserTOTPMethodRepository, recoveryRepo model.RecoveryCodeMethodRepository, env library.Env) model.TwoFactorMethodService {
+	return &TwoFactorService{
+		repo:         repo,
+		recoveryRepo: recoveryRepo,
+		env:          env,
+	}
+}
+
+// Enroll creates a new secret and set of recovery codes for the user, to
+// be confirmed with a first code before it is required at login.
+func (t TwoFactorService) Enroll(ctx context.Context, userId int64, accountName string) (*model.TwoFactorEnrollment, error) {
+	if t.env.TwoFactor.EncryptionKey == "" {
+		return nil, model.ErrTwoFactorNotConfigured
+	}
+
+	existing, err := t.repo.WithContext(ctx).FindByUserId(userId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil && existing.ConfirmedAt != nil {
+		return nil, model.ErrTwoFactorAlreadyEnabled
+	}
+
+	issuer := t.env.TwoFactor.Issuer
+	if issuer == "" {
+		issuer = constants.DefaultTwoFactorIssuer
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      uint(totpOpts.Period),
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	secret, err := library.EncryptSecret(t.env.TwoFactor.EncryptionKey, key.Secret())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+	if _, err := t.repo.WithContext(ctx).Save(&model.UserTOTP{UserId: userId, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := t.replaceRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render qr code: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to render qr code: %w", err)
+	}
+
+	return &model.TwoFactorEnrollment{
+		Secret:        key.Secret(),
+		OTPAuthURI:    key.URL(),
+		QRCode:        "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// Confirm turns on two-factor login once the user proves their app
+// produces valid codes.
+func (t TwoFactorService) Confirm(ctx context.Context, userId int64, code string) error {
+	enrollment, err := t.repo.WithContext(ctx).FindByUserId(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+	if enrollment.ConfirmedAt != nil {
+		return model.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok, err := t.match(enrollment, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return model.ErrInvalidTwoFactorCode
+	}
+
+	return t.repo.WithContext(ctx).Confirm(userId, step)
+}
+
+func (t TwoFactorService) IsEnabled(ctx context.Context, userId int64) (bool, error) {
+	enrollment, err := t.repo.WithContext(ctx).FindByUserId(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return enrollment.ConfirmedAt != nil, nil
+}
+
+// Check accepts a TOTP code or an unused recovery code, each once. After
+// DefaultTwoFactorMaxAttempts wrong codes further attempts are refused
+// until DefaultTwoFactorLockout has passed. Every attempt is counted before
+// the code is compared and the count is cleared on success.
+func (t TwoFactorService) Check(ctx context.Context, userId int64, code string) error {
+	enrollment, err := t.repo.WithContext(ctx).FindByUserId(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+	if enrollment.ConfirmedAt == nil {
+		return model.ErrTwoFactorNotEnrolled
+	}
+
+	claimed, err := t.repo.WithContext(ctx).ClaimAttempt(userId, constants.DefaultTwoFactorMaxAttempts, time.Now().Add(-constants.DefaultTwoFactorLockout))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return model.ErrTooManyTwoFactorAttempts
+	}
+
+	var used bool
+	if recoveryCode, ok := normalizeRecoveryCode(code); ok {
+		used, err = t.useRecoveryCode(ctx, userId, recoveryCode)
+	} else {
+		used, err = t.useTOTPCode(ctx, enrollment, code)
+	}
+	if err != nil {
+		return err
+	}
+	if !used {
+		return model.ErrInvalidTwoFactorCode
+	}
+
+	return t.repo.WithContext(ctx).ResetAttempts(userId)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a
+// current TOTP or recovery code.
+func (t TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userId int64, code string) (*model.RecoveryCodesOutput, error) {
+	if err := t.Check(ctx, userId, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := t.replaceRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RecoveryCodesOutput{
+		RecoveryCodes: recoveryCodes,
+		Remaining:     int64(len(recoveryCodes)),
+	}, nil
+}
+
+func (t TwoFactorService) CountRecoveryCodes(ctx context.Context, userId int64) (*model.RecoveryCodesOutput, error) {
+	enabled, err := t.IsEnabled(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, model.ErrTwoFactorNotEnrolled
+	}
+
+	remaining, err := t.recoveryRepo.WithContext(ctx).CountUnused(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodesOutput{Remaining: remaining}, nil
+}
+
+// replaceRecoveryCodes stores hashes of a new set of codes and returns the
+// codes themselves, formatted as xxxxx-xxxxx.
+func (t TwoFactorService) replaceRecoveryCodes(ctx context.Context, userId int64) ([]string, error) {
+	codes := make([]string, 0, constants.DefaultRecoveryCodeCount)
+	hashes := make([]string, 0, constants.DefaultRecoveryCodeCount)
+	for range constants.DefaultRecoveryCodeCount {
+		random := make([]byte, 10)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		for i, b := range random {
+			random[i] = recoveryCodeAlphabet[b%32]
+		}
+
+		hash, err := library.HashPassword(string(random))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		codes = append(codes, string(random[:5])+"-"+string(random[5:]))
+		hashes = append(hashes, hash)
+	}
+
+	if err := t.recoveryRepo.WithContext(ctx).Replace(userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode tells recovery codes from six digit TOTP codes and
+// strips the separator users may or may not type.
+func normalizeRecoveryCode(code string) (string, bool) {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return code, len(code) == 10
+}
+
+func (t TwoFactorService) useRecoveryCode(ctx context.Context, userId int64, code string) (bool, error) {
+	unused, err := t.recoveryRepo.WithContext(ctx).FindUnused(userId)
+	if err != nil {
+		return false, err
+	}
+	for _, recoveryCode := range unused {
+		if library.CheckPasswordHash(code, recoveryCode.CodeHash) {
+			return t.recoveryRepo.WithContext(ctx).MarkUsed(recoveryCode.Id)
+		}
+	}
+	return false, nil
+}
+
+func (t TwoFactorService) useTOTPCode(ctx context.Context, enrollment *model.UserTOTP, code string) (bool, error) {
+	step, ok, err := t.match(enrollment, code)
+	if err != nil || !ok {
+		return false, err
+	}
+	return t.repo.WithContext(ctx).UseStep(enrollment.UserId, step)
+}
+
+// match finds the time step code belongs to, allowing one step of clock
+// drift either way.
+func (t TwoFactorService) match(enrollment *model.UserTOTP, code string) (int64, bool, error) {
+	secret, err := library.DecryptSecret(t.env.TwoFactor.EncryptionKey, enrollment.Secret)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	current := time.Now().Unix() / int64(totpOpts.Period)
+	for step := current - 1; step <= current+1; step++ {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*int64(totpOpts.Period), 0), totpOpts)
+		if err != nil {
+			return 0, false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
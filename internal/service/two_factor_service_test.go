@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+// memoryTOTP keeps enrollments the way the user_totp table does; attempts
+// are never limited.
+type memoryTOTP struct {
+	model.UserTOTPMethodRepository
+	enrollments map[int64]*model.UserTOTP
+}
+
+func (m *memoryTOTP) WithContext(ctx context.Context) model.UserTOTPMethodRepository {
+	return m
+}
+
+func (m *memoryTOTP) FindByUserId(userId int64) (*model.UserTOTP, error) {
+	enrollment, ok := m.enrollments[userId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return enrollment, nil
+}
+
+func (m *memoryTOTP) Save(data *model.UserTOTP) (*model.UserTOTP, error) {
+	m.enrollments[data.UserId] = data
+	return data, nil
+}
+
+func (m *memoryTOTP) Confirm(userId int64, step int64) error {
+	now := time.Now()
+	m.enrollments[userId].ConfirmedAt = &now
+	m.enrollments[userId].LastUsedStep = step
+	return nil
+}
+
+func (m *memoryTOTP) UseStep(userId int64, step int64) (bool, error) {
+	enrollment := m.enrollments[userId]
+	if enrollment.LastUsedStep >= step {
+		return false, nil
+	}
+	enrollment.LastUsedStep = step
+	return true, nil
+}
+
+func (m *memoryTOTP) ClaimAttempt(userId int64, maxAttempts int, lockedSince time.Time) (bool, error) {
+	return true, nil
+}
+
+func (m *memoryTOTP) ResetAttempts(userId int64) error {
+	return nil
+}
+
+type memoryRecoveryCodes struct {
+	model.RecoveryCodeMethodRepository
+	codes []*model.RecoveryCode
+}
+
+func (m *memoryRecoveryCodes) WithContext(ctx context.Context) model.RecoveryCodeMethodRepository {
+	return m
+}
+
+func (m *memoryRecoveryCodes) Replace(userId int64, codeHashes []string) error {
+	m.codes = nil
+	for i, hash := range codeHashes {
+		m.codes = append(m.codes, &model.RecoveryCode{Id: int64(i + 1), UserId: userId, CodeHash: hash})
+	}
+	return nil
+}
+
+func (m *memoryRecoveryCodes) FindUnused(userId int64) ([]*model.RecoveryCode, error) {
+	var unused []*model.RecoveryCode
+	for _, code := range m.codes {
+		if code.UserId == userId && code.UsedAt == nil {
+			unused = append(unused, code)
+		}
+	}
+	return unused, nil
+}
+
+func (m *memoryRecoveryCodes) CountUnused(userId int64) (int64, error) {
+	unused, err := m.FindUnused(userId)
+	return int64(len(unused)), err
+}
+
+func (m *memoryRecoveryCodes) MarkUsed(id int64) (bool, error) {
+	for _, code := range m.codes {
+		if code.Id == id && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// totpCode is the code an authenticator app shows during step.
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(secret, time.Unix(step*int64(totpOpts.Period), 0), totpOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTwoFactorCodesAreUsedOnce(t *testing.T) {
+	ctx := context.Background()
+	var env library.Env
+	env.TwoFactor.EncryptionKey = "0123456789abcdef0123456789abcdef"
+	service := NewTwoFactorService(&memoryTOTP{enrollments: make(map[int64]*model.UserTOTP)}, &memoryRecoveryCodes{}, env)
+
+	enrollment, err := service.Enroll(ctx, 7, "bob@example.com")
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	step := time.Now().Unix() / int64(totpOpts.Period)
+	if err := service.Confirm(ctx, 7, totpCode(t, enrollment.Secret, step)); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	// the step that confirmed enrollment can not log in
+	if err := service.Check(ctx, 7, totpCode(t, enrollment.Secret, step)); !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		t.Fatalf("code used to confirm: err = %v, want %v", err, model.ErrInvalidTwoFactorCode)
+	}
+
+	// a later step works once, and earlier steps stay used
+	next := totpCode(t, enrollment.Secret, step+1)
+	if err := service.Check(ctx, 7, next); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	for name, code := range map[string]string{"same": next, "earlier": totpCode(t, enrollment.Secret, step-1)} {
+		if err := service.Check(ctx, 7, code); !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			t.Fatalf("%s step again: err = %v, want %v", name, err, model.ErrInvalidTwoFactorCode)
+		}
+	}
+}
+
+func TestRecoveryCodesAreUsedOnce(t *testing.T) {
+	ctx := context.Background()
+	var env library.Env
+	env.TwoFactor.EncryptionKey = "0123456789abcdef0123456789abcdef"
+	service := NewTwoFactorService(&memoryTOTP{enrollments: make(map[int64]*model.UserTOTP)}, &memoryRecoveryCodes{}, env)
+
+	enrollment, err := service.Enroll(ctx, 7, "bob@example.com")
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	step := time.Now().Unix() / int64(totpOpts.Period)
+	if err := service.Confirm(ctx, 7, totpCode(t, enrollment.Secret, step)); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	// typed without the separator, as users often do
+	recoveryCode := enrollment.RecoveryCodes[0]
+	if err := service.Check(ctx, 7, recoveryCode[:5]+recoveryCode[6:]); err != nil {
+		t.Fatalf("Check with a recovery code: %v", err)
+	}
+	if err := service.Check(ctx, 7, recoveryCode); !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		t.Fatalf("recovery code again: err = %v, want %v", err, model.ErrInvalidTwoFactorCode)
+	}
+
+	remaining, err := service.CountRecoveryCodes(ctx, 7)
+	if err != nil {
+		t.Fatalf("CountRecoveryCodes: %v", err)
+	}
+	if want := int64(len(enrollment.RecoveryCodes) - 1); remaining.Remaining != want {
+		t.Fatalf("remaining recovery codes = %d, want %d", remaining.Remaining, want)
+	}
+}
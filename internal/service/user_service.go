@@ -3,22 +3,30 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/labstack/echo/v4"
 	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
 	"github.com/petershaan12/go-auth-clean-arch/resource/model"
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	repo model.UserMethodRepository
-	env  library.Env
+	repo        model.UserMethodRepository
+	roleRepo    model.RoleMethodRepository
+	sessionRepo model.SessionMethodRepository
+	policy      model.PasswordPolicy
+	env         library.Env
 }
 
-func NewUserService(repo model.UserMethodRepository, env library.Env) model.UserMethodService {
+func NewUserService(repo model.UserMethodRepository, roleRepo model.RoleMethodRepository, sessionRepo model.SessionMethodRepository, policy model.PasswordPolicy, env library.Env) model.UserMethodService {
 	return &UserService{
-		repo: repo,
-		env:  env,
+		repo:        repo,
+		roleRepo:    roleRepo,
+		sessionRepo: sessionRepo,
+		policy:      policy,
+		env:         env,
 	}
 }
 
@@ -31,55 +39,82 @@ func (u *UserService) List(ctx context.Context) (result []*model.User, err error
 	return
 }
 
+// Create is the admin path: the role is chosen by the caller and the email
+// address is trusted without verification.
 func (u *UserService) Create(tx *gorm.DB, req *model.CreateUserRequest) (*model.User, error) {
+	return u.create(tx.Statement.Context, &model.User{
+		Username:      req.Username,
+		Email:         req.Email,
+		Fullname:      req.Fullname,
+		RoleId:        req.RoleID,
+		EmailVerified: true,
+	}, req.Password)
+}
 
-	// 1) Cek duplikasi email
+// Register is self-service sign-up: the account always gets
+// registration.defaultRole and starts with an unverified email address.
+func (u *UserService) Register(tx *gorm.DB, req *model.RegisterRequest) (*model.User, error) {
 	ctx := tx.Statement.Context
+
+	roleName := u.env.Registration.DefaultRole
+	if roleName == "" {
+		roleName = constants.DefaultRegistrationRole
+	}
+	role, err := u.roleRepo.WithContext(ctx).FindByName(roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registration role %q: %w", roleName, err)
+	}
+
+	return u.create(ctx, &model.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Fullname: req.Fullname,
+		RoleId:   int64(role.ID),
+	}, req.Password)
+}
+
+func (u *UserService) create(ctx context.Context, user *model.User, password string) (*model.User, error) {
+	// 1) Cek duplikasi email
 	emailFilter := []*model.GormWhere{
-		{Where: "users.email = ?", Value: []any{req.Email}},
+		{Where: "users.email = ?", Value: []any{user.Email}},
 	}
 	emailTotal, err := u.repo.WithContext(ctx).Count(emailFilter)
 	if err != nil {
 		return nil, err
 	}
 	if emailTotal > 0 {
-		return nil, errors.New("email already in use")
+		return nil, model.ErrEmailInUse
 	}
 
 	// 2) Cek duplikasi username
 	usernameFilter := []*model.GormWhere{
-		{Where: "users.username = ?", Value: []any{req.Username}},
+		{Where: "users.username = ?", Value: []any{user.Username}},
 	}
 	usernameTotal, err := u.repo.WithContext(ctx).Count(usernameFilter)
 	if err != nil {
 		return nil, err
 	}
 	if usernameTotal > 0 {
-		return nil, errors.New("username already in use")
+		return nil, model.ErrUsernameInUse
 	}
 
-	// 3) Hash password
-	hashPass, err := library.HashPassword(req.Password)
-	if err != nil {
+	// 3) Cek password policy, lalu hash
+	if err := u.policy.Validate(password, user.Username, user.Email); err != nil {
 		return nil, err
 	}
-
-	// 4) Map ke entity
-	user := &model.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Fullname: req.Fullname,
-		RoleId:   req.RoleID,
-		Password: hashPass,
+	hashPass, err := library.HashPassword(password)
+	if err != nil {
+		return nil, err
 	}
+	user.Password = hashPass
 
-	// 5) Simpan
+	// 4) Simpan
 	created, err := u.repo.WithContext(ctx).Create(user)
 	if err != nil {
 		return nil, err
 	}
 
-	// 6) Sanitasi sebelum return (kalau field sensitif belum ditandai json:"-")
+	// 5) Sanitasi sebelum return (kalau field sensitif belum ditandai json:"-")
 	user.Password = ""
 
 	return created, nil
@@ -110,7 +145,7 @@ func (u *UserService) Update(c echo.Context, tx *gorm.DB, id int, req *model.Upd
 			return nil, err
 		}
 		if emailTotal > 0 {
-			return nil, errors.New("email already in use")
+			return nil, model.ErrEmailInUse
 		}
 	}
 
@@ -124,13 +159,33 @@ func (u *UserService) Update(c echo.Context, tx *gorm.DB, id int, req *model.Upd
 			return nil, err
 		}
 		if usernameTotal > 0 {
-			return nil, errors.New("username already in use")
+			return nil, model.ErrUsernameInUse
 		}
 	}
 
-	// 4) Handle password update jika disediakan
+	// 4) Ambil user existing untuk update
+	existingUser, err := u.repo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ?", Value: []any{id64}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 5) Handle password update jika disediakan, dicek terhadap username
+	// dan email yang baru kalau ikut diubah
 	var pwdHash string
 	if req.Password != "" {
+		username, email := existingUser.Username, existingUser.Email
+		if req.Username != "" {
+			username = req.Username
+		}
+		if req.Email != "" {
+			email = req.Email
+		}
+		if err := u.policy.Validate(req.Password, username, email); err != nil {
+			return nil, err
+		}
+
 		hashPass, err := library.HashPassword(req.Password)
 		if err != nil {
 			return nil, err
@@ -138,14 +193,6 @@ func (u *UserService) Update(c echo.Context, tx *gorm.DB, id int, req *model.Upd
 		pwdHash = hashPass
 	}
 
-	// 5) Ambil user existing untuk update
-	existingUser, err := u.repo.WithContext(ctx).FindBy([]*model.GormWhere{
-		{Where: "users.id = ?", Value: []any{id64}},
-	})
-	if err != nil {
-		return nil, err
-	}
-
 	// 6) Update fields
 	if req.Username != "" {
 		existingUser.Username = req.Username
@@ -191,7 +238,15 @@ func (u *UserService) Delete(c echo.Context, tx *gorm.DB, id int) error {
 		return errors.New("user not found")
 	}
 
-	// 2) Hapus user
+	// 2) Token yang masih beredar langsung tidak berlaku
+	if err := u.repo.WithContext(ctx).IncrementSessionVersion(ctx, id64); err != nil {
+		return err
+	}
+	if err := u.sessionRepo.WithContext(ctx).RevokeAllByUser(id64); err != nil {
+		return err
+	}
+
+	// 3) Hapus user
 	err = u.repo.WithContext(ctx).Delete(id64)
 	if err != nil {
 		return err
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+// memoryUsers keeps users and their session versions the way the users
+// table does, including hiding soft deleted rows.
+type memoryUsers struct {
+	model.UserMethodRepository
+	users            map[int64]*model.User
+	passwordAttempts map[int64]int
+	passwordFailedAt map[int64]time.Time
+}
+
+func newMemoryUsers(users ...*model.User) *memoryUsers {
+	m := &memoryUsers{
+		users:            make(map[int64]*model.User),
+		passwordAttempts: make(map[int64]int),
+		passwordFailedAt: make(map[int64]time.Time),
+	}
+	for _, user := range users {
+		if user.SessionVersion == 0 {
+			user.SessionVersion = 1
+		}
+		m.users[user.Id] = user
+	}
+	return m
+}
+
+func (m *memoryUsers) WithContext(ctx context.Context) model.UserMethodRepository {
+	return m
+}
+
+func (m *memoryUsers) live(id int64) (*model.User, bool) {
+	user, ok := m.users[id]
+	if !ok || user.DeletedAt != nil {
+		return nil, false
+	}
+	return user, true
+}
+
+func (m *memoryUsers) FindBy(filter []*model.GormWhere) (*model.User, error) {
+	for _, user := range m.users {
+		if user.DeletedAt != nil {
+			continue
+		}
+		value := filter[0].Value[0]
+		if strings.Contains(filter[0].Where, "email") && user.Email == value {
+			return user, nil
+		}
+		if id, ok := value.(int64); ok && user.Id == id {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryUsers) MarkEmailVerified(id int64) error {
+	if user, ok := m.live(id); ok {
+		user.EmailVerified = true
+	}
+	return nil
+}
+
+func (m *memoryUsers) ClaimPasswordAttempt(id int64, maxAttempts int, lockedSince time.Time) (bool, error) {
+	if _, ok := m.live(id); !ok {
+		return false, nil
+	}
+	failedAt, failed := m.passwordFailedAt[id]
+	stale := !failed || failedAt.Before(lockedSince)
+	if !stale && m.passwordAttempts[id] >= maxAttempts {
+		return false, nil
+	}
+	if stale {
+		m.passwordAttempts[id] = 0
+	}
+	m.passwordAttempts[id]++
+	m.passwordFailedAt[id] = time.Now()
+	return true, nil
+}
+
+func (m *memoryUsers) ResetPasswordAttempts(id int64) error {
+	m.passwordAttempts[id] = 0
+	return nil
+}
+
+func (m *memoryUsers) Count(filter []*model.GormWhere) (int64, error) {
+	id, _ := filter[0].Value[0].(int)
+	if _, ok := m.users[int64(id)]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m *memoryUsers) Delete(id int64) error {
+	if user, ok := m.live(id); ok {
+		now := time.Now()
+		user.DeletedAt = &now
+	}
+	return nil
+}
+
+func (m *memoryUsers) IncrementSessionVersion(ctx context.Context, userId int64) error {
+	if user, ok := m.live(userId); ok {
+		user.SessionVersion++
+	}
+	return nil
+}
+
+func (m *memoryUsers) GetSessionVersion(ctx context.Context, userId int64) (int, error) {
+	user, ok := m.live(userId)
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return user.SessionVersion, nil
+}
+
+// memorySessions keeps created sessions and records which users had
+// every session revoked.
+type memorySessions struct {
+	model.SessionMethodRepository
+	sessions     []*model.Session
+	revokedUsers []int64
+}
+
+func (m *memorySessions) WithContext(ctx context.Context) model.SessionMethodRepository {
+	return m
+}
+
+func (m *memorySessions) Create(data *model.Session) (*model.Session, error) {
+	m.sessions = append(m.sessions, data)
+	return data, nil
+}
+
+func (m *memorySessions) Touch(sessionId string) error {
+	return nil
+}
+
+func (m *memorySessions) Revoke(sessionId string) error {
+	now := time.Now()
+	for _, session := range m.sessions {
+		if session.Id == sessionId && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memorySessions) GetSessionActivity(ctx context.Context, sessionId string) (*token.SessionActivity, error) {
+	for _, session := range m.sessions {
+		if session.Id == sessionId {
+			return &token.SessionActivity{
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				RevokedAt:  session.RevokedAt,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memorySessions) MarkSessionSeen(ctx context.Context, sessionId string) error {
+	return nil
+}
+
+func (m *memorySessions) RevokeAllByUser(userId int64) error {
+	m.revokedUsers = append(m.revokedUsers, userId)
+	return nil
+}
+
+func newTestMaker(t *testing.T, opts token.Options) token.Maker {
+	t.Helper()
+	keyring, err := token.NewStaticKeyring("this is a 32-byte key for Paseto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	maker, err := token.NewPaseto(token.VersionV4, keyring, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return maker
+}
+
+func TestDeleteUserRevokesIssuedTokens(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUsers(&model.User{Id: 7, Email: "bob@example.com"})
+	sessions := &memorySessions{}
+	maker := newTestMaker(t, token.Options{Stores: token.Stores{Users: users}})
+	service := NewUserService(users, nil, sessions, nil, library.Env{})
+
+	accessToken, _, err := maker.CreateToken(ctx, &token.TokenParams{UserID: 7, Email: "bob@example.com"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := maker.VerifyToken(ctx, accessToken); err != nil {
+		t.Fatalf("VerifyToken before delete: %v", err)
+	}
+
+	tx := &gorm.DB{Statement: &gorm.Statement{Context: ctx}}
+	if err := service.Delete(nil, tx, 7); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := maker.VerifyToken(ctx, accessToken); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Fatalf("VerifyToken after delete: err = %v, want %v", err, token.ErrTokenRevoked)
+	}
+	if len(sessions.revokedUsers) != 1 || sessions.revokedUsers[0] != 7 {
+		t.Fatalf("revoked sessions of users %v, want [7]", sessions.revokedUsers)
+	}
+}
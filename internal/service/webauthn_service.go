@@ -0,0 +1,330 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+	"gorm.io/gorm"
+)
+
+const (
+	ceremonyRegister = "webauthn_register:"
+	ceremonyLogin    = "webauthn_login:"
+)
+
+type WebAuthnService struct {
+	webAuthn   *webauthn.WebAuthn
+	repo       model.WebAuthnCredentialMethodRepository
+	userRepo   model.UserMethodRepository
+	challenges model.ChallengeStore
+}
+
+// NewWebAuthnService sets up the relying party from the webAuthn config.
+// Without an rpId and origins every ceremony fails with
+// ErrWebAuthnNotConfigured.
+func NewWebAuthnService(repo model.WebAuthnCredentialMethodRepository, userRepo model.UserMethodRepository, challenges model.ChallengeStore, env library.Env) model.WebAuthnMethodService {
+	service := &WebAuthnService{
+		repo:       repo,
+		userRepo:   userRepo,
+		challenges: challenges,
+	}
+	if env.WebAuthn.RPID == "" {
+		return service
+	}
+
+	displayName := env.WebAuthn.RPDisplayName
+	if displayName == "" {
+		displayName = constants.DefaultWebAuthnDisplayName
+	}
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    constants.DefaultWebAuthnCeremonyTimeout,
+		TimeoutUVD: constants.DefaultWebAuthnCeremonyTimeout,
+	}
+
+	// passwordless login needs discoverable credentials, and user
+	// verification is what makes a passkey more than one factor
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          env.WebAuthn.RPID,
+		RPDisplayName: displayName,
+		RPOrigins:     env.WebAuthn.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		log.Println("webauthn disabled:", err.Error())
+		return service
+	}
+	service.webAuthn = w
+	return service
+}
+
+// BeginRegistration starts adding a passkey to the user's account. Keys
+// the user already registered are excluded.
+func (w WebAuthnService) BeginRegistration(ctx context.Context, userId int64) (*model.WebAuthnBeginOutput, error) {
+	if w.webAuthn == nil {
+		return nil, model.ErrWebAuthnNotConfigured
+	}
+
+	user, err := w.loadUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()
+	creation, session, err := w.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, err
+	}
+	return w.startCeremony(ctx, ceremonyRegister, creation, session)
+}
+
+func (w WebAuthnService) FinishRegistration(ctx context.Context, userId int64, req *model.WebAuthnRegisterReq) (*model.WebAuthnCredential, error) {
+	if w.webAuthn == nil {
+		return nil, model.ErrWebAuthnNotConfigured
+	}
+
+	session, err := w.takeCeremony(ctx, ceremonyRegister, req.CeremonyId)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := w.loadUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrWebAuthnVerification, err)
+	}
+	credential, err := w.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrWebAuthnVerification, err)
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return w.repo.WithContext(ctx).Create(&model.WebAuthnCredential{
+		UserId:          userId,
+		CredentialId:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      strings.Join(transports, ","),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Label:           req.Label,
+	})
+}
+
+// BeginLogin starts a passwordless login. No account is named up front;
+// the authenticator picks a passkey and reports whose it is.
+func (w WebAuthnService) BeginLogin(ctx context.Context) (*model.WebAuthnBeginOutput, error) {
+	if w.webAuthn == nil {
+		return nil, model.ErrWebAuthnNotConfigured
+	}
+
+	assertion, session, err := w.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+	return w.startCeremony(ctx, ceremonyLogin, assertion, session)
+}
+
+// FinishLogin verifies the assertion and returns the user it belongs to.
+// A signature counter that did not increase means the key may have been
+// copied, and the login is refused.
+func (w WebAuthnService) FinishLogin(ctx context.Context, req *model.WebAuthnLoginReq) (*model.User, error) {
+	if w.webAuthn == nil {
+		return nil, model.ErrWebAuthnNotConfigured
+	}
+
+	session, err := w.takeCeremony(ctx, ceremonyLogin, req.CeremonyId)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrWebAuthnVerification, err)
+	}
+
+	var user *webAuthnUser
+	credential, err := w.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userId, err := parseWebAuthnUserHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err = w.loadUser(ctx, userId)
+		return user, err
+	}, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrWebAuthnVerification, err)
+	}
+
+	record := user.record(credential.ID)
+	if record == nil {
+		return nil, model.ErrWebAuthnCredentialUnknown
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, model.ErrWebAuthnCredentialCloned
+	}
+
+	err = w.repo.WithContext(ctx).RecordUse(record.Id, credential.Authenticator.SignCount, credential.Flags.BackupState)
+	if err != nil {
+		return nil, err
+	}
+	return user.user, nil
+}
+
+// startCeremony stores session until the finish request and hands the
+// client the options for the browser under a new ceremony id.
+func (w WebAuthnService) startCeremony(ctx context.Context, kind string, options any, session *webauthn.SessionData) (*model.WebAuthnBeginOutput, error) {
+	state, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	rawOptions, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	ceremonyId := uuid.NewString()
+	if err := w.challenges.Put(ctx, kind+ceremonyId, state, constants.DefaultWebAuthnCeremonyTimeout); err != nil {
+		return nil, fmt.Errorf("failed to store webauthn ceremony: %w", err)
+	}
+
+	return &model.WebAuthnBeginOutput{
+		CeremonyId: ceremonyId,
+		Options:    rawOptions,
+	}, nil
+}
+
+// takeCeremony loads and forgets a ceremony, so each challenge can be
+// answered once.
+func (w WebAuthnService) takeCeremony(ctx context.Context, kind string, ceremonyId string) (*webauthn.SessionData, error) {
+	state, ok := w.challenges.Take(ctx, kind+ceremonyId)
+	if !ok {
+		return nil, model.ErrWebAuthnCeremonyNotFound
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(state, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (w WebAuthnService) loadUser(ctx context.Context, userId int64) (*webAuthnUser, error) {
+	user, err := w.userRepo.WithContext(ctx).FindBy([]*model.GormWhere{
+		{Where: "users.id = ? AND users.deleted_at IS NULL", Value: []any{userId}},
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	records, err := w.repo.WithContext(ctx).FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webAuthnUser{
+		user:    user,
+		records: records,
+	}, nil
+}
+
+// webAuthnUser presents a user and their stored credentials as a
+// webauthn.User.
+type webAuthnUser struct {
+	user    *model.User
+	records []*model.WebAuthnCredential
+}
+
+// WebAuthnID is the user handle stored in the passkey: the user id, so the
+// authenticator never holds the email address.
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(u.user.Id))
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.user.Fullname != "" {
+		return u.user.Fullname
+	}
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.records))
+	for _, record := range u.records {
+		var transports []protocol.AuthenticatorTransport
+		if record.Transports != "" {
+			for _, transport := range strings.Split(record.Transports, ",") {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              record.CredentialId,
+			PublicKey:       record.PublicKey,
+			AttestationType: record.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: record.BackupEligible,
+				BackupState:    record.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    record.AAGUID,
+				SignCount: record.SignCount,
+			},
+		})
+	}
+	return credentials
+}
+
+func (u *webAuthnUser) record(credentialId []byte) *model.WebAuthnCredential {
+	for _, record := range u.records {
+		if bytes.Equal(record.CredentialId, credentialId) {
+			return record
+		}
+	}
+	return nil
+}
+
+func parseWebAuthnUserHandle(userHandle []byte) (int64, error) {
+	if len(userHandle) != 8 {
+		return 0, errors.New("unknown user handle")
+	}
+	return int64(binary.BigEndian.Uint64(userHandle)), nil
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/petershaan12/go-auth-clean-arch/internal/cache"
+	"github.com/petershaan12/go-auth-clean-arch/package/library"
+	"github.com/petershaan12/go-auth-clean-arch/resource/model"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+var b64 = base64.RawURLEncoding
+
+// softAuthenticator is a platform authenticator holding one P-256 passkey.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialId := make([]byte, 16)
+	if _, err := rand.Read(credentialId); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, credentialId: credentialId}
+}
+
+// create answers navigator.credentials.create with a "none" attestation.
+func (a *softAuthenticator) create(t *testing.T, options json.RawMessage) json.RawMessage {
+	t.Helper()
+	var creation struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			User      struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &creation); err != nil {
+		t.Fatal(err)
+	}
+	userHandle, err := b64.DecodeString(creation.PublicKey.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.userHandle = userHandle
+
+	coseKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flags: user present, user verified, attested credential data
+	authData := a.authData(0x45)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialId)))
+	authData = append(authData, a.credentialId...)
+	authData = append(authData, coseKey...)
+
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(t, map[string]any{
+		"clientDataJSON":    b64.EncodeToString(clientData(t, "webauthn.create", creation.PublicKey.Challenge)),
+		"attestationObject": b64.EncodeToString(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get answers navigator.credentials.get, counting the signature.
+func (a *softAuthenticator) get(t *testing.T, options json.RawMessage) json.RawMessage {
+	t.Helper()
+	var assertion struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &assertion); err != nil {
+		t.Fatal(err)
+	}
+
+	a.signCount++
+	// flags: user present, user verified
+	authData := a.authData(0x05)
+	clientDataJSON := clientData(t, "webauthn.get", assertion.PublicKey.Challenge)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(t, map[string]any{
+		"clientDataJSON":    b64.EncodeToString(clientDataJSON),
+		"authenticatorData": b64.EncodeToString(authData),
+		"signature":         b64.EncodeToString(signature),
+		"userHandle":        b64.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(testRPID))
+	authData := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, a.signCount)
+}
+
+func (a *softAuthenticator) credential(t *testing.T, response map[string]any) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{
+		"id":       b64.EncodeToString(a.credentialId),
+		"rawId":    b64.EncodeToString(a.credentialId),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func clientData(t *testing.T, ceremony string, challenge string) []byte {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+type memoryWebAuthnCredentials struct {
+	records []*model.WebAuthnCredential
+}
+
+func (m *memoryWebAuthnCredentials) WithContext(ctx context.Context) model.WebAuthnCredentialMethodRepository {
+	return m
+}
+
+func (m *memoryWebAuthnCredentials) FindByUserId(userId int64) ([]*model.WebAuthnCredential, error) {
+	var result []*model.WebAuthnCredential
+	for _, record := range m.records {
+		if record.UserId == userId {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryWebAuthnCredentials) Create(req *model.WebAuthnCredential) (*model.WebAuthnCredential, error) {
+	req.Id = int64(len(m.records) + 1)
+	m.records = append(m.records, req)
+	return req, nil
+}
+
+func (m *memoryWebAuthnCredentials) RecordUse(id int64, signCount uint32, backupState bool) error {
+	for _, record := range m.records {
+		if record.Id == id {
+			now := time.Now()
+			record.SignCount = signCount
+			record.BackupState = backupState
+			record.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+// singleUser serves the one user the tests log in as; the other
+// repository methods are not used by the WebAuthn service.
+type singleUser struct {
+	model.UserMethodRepository
+	user *model.User
+}
+
+func (s *singleUser) WithContext(ctx context.Context) model.UserMethodRepository {
+	return s
+}
+
+func (s *singleUser) FindBy(filter []*model.GormWhere) (*model.User, error) {
+	return s.user, nil
+}
+
+func newTestWebAuthnService(t *testing.T) (model.WebAuthnMethodService, *memoryWebAuthnCredentials) {
+	t.Helper()
+	var env library.Env
+	env.WebAuthn.RPID = testRPID
+	env.WebAuthn.RPOrigins = []string{testOrigin}
+
+	credentials := &memoryWebAuthnCredentials{}
+	users := &singleUser{user: &model.User{Id: 42, Username: "alice", Email: "alice@example.com"}}
+	return NewWebAuthnService(credentials, users, cache.NewMemoryChallenge(), env), credentials
+}
+
+// register runs a full registration ceremony for the test user.
+func register(t *testing.T, service model.WebAuthnMethodService, authenticator *softAuthenticator) {
+	t.Helper()
+	ctx := context.Background()
+	begin, err := service.BeginRegistration(ctx, 42)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	_, err = service.FinishRegistration(ctx, 42, &model.WebAuthnRegisterReq{
+		CeremonyId: begin.CeremonyId,
+		Credential: authenticator.create(t, begin.Options),
+		Label:      "laptop",
+	})
+	if err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+}
+
+func TestWebAuthnRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	service, credentials := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+
+	register(t, service, authenticator)
+	if len(credentials.records) != 1 || credentials.records[0].UserId != 42 {
+		t.Fatalf("stored credentials = %+v, want one for user 42", credentials.records)
+	}
+
+	for i := 0; i < 2; i++ {
+		begin, err := service.BeginLogin(ctx)
+		if err != nil {
+			t.Fatalf("BeginLogin: %v", err)
+		}
+		user, err := service.FinishLogin(ctx, &model.WebAuthnLoginReq{
+			CeremonyId: begin.CeremonyId,
+			Credential: authenticator.get(t, begin.Options),
+		})
+		if err != nil {
+			t.Fatalf("FinishLogin: %v", err)
+		}
+		if user.Id != 42 {
+			t.Fatalf("FinishLogin user = %d, want 42", user.Id)
+		}
+	}
+
+	if got := credentials.records[0].SignCount; got != authenticator.signCount {
+		t.Fatalf("stored sign count = %d, want %d", got, authenticator.signCount)
+	}
+}
+
+func TestWebAuthnCeremonyCanNotBeReplayed(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+	register(t, service, authenticator)
+
+	begin, err := service.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	req := &model.WebAuthnLoginReq{
+		CeremonyId: begin.CeremonyId,
+		Credential: authenticator.get(t, begin.Options),
+	}
+	if _, err := service.FinishLogin(ctx, req); err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+
+	// the same ceremony is gone once answered
+	if _, err := service.FinishLogin(ctx, req); !errors.Is(err, model.ErrWebAuthnCeremonyNotFound) {
+		t.Fatalf("replayed ceremony: err = %v, want %v", err, model.ErrWebAuthnCeremonyNotFound)
+	}
+
+	// and the signed assertion does not answer a new challenge
+	next, err := service.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	req.CeremonyId = next.CeremonyId
+	if _, err := service.FinishLogin(ctx, req); !errors.Is(err, model.ErrWebAuthnVerification) {
+		t.Fatalf("replayed assertion: err = %v, want %v", err, model.ErrWebAuthnVerification)
+	}
+}
+
+func TestWebAuthnRegistrationCeremonyCanNotBeReplayed(t *testing.T) {
+	ctx := context.Background()
+	service, credentials := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+
+	begin, err := service.BeginRegistration(ctx, 42)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	req := &model.WebAuthnRegisterReq{
+		CeremonyId: begin.CeremonyId,
+		Credential: authenticator.create(t, begin.Options),
+	}
+	if _, err := service.FinishRegistration(ctx, 42, req); err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+	if _, err := service.FinishRegistration(ctx, 42, req); !errors.Is(err, model.ErrWebAuthnCeremonyNotFound) {
+		t.Fatalf("replayed ceremony: err = %v, want %v", err, model.ErrWebAuthnCeremonyNotFound)
+	}
+	if len(credentials.records) != 1 {
+		t.Fatalf("stored %d credentials, want 1", len(credentials.records))
+	}
+}
@@ -0,0 +1,246 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const DPoPHeader = "DPoP"
+
+var (
+	ErrDPoPProofMissing = errors.New("missing DPoP proof")
+	ErrDPoPProofInvalid = errors.New("invalid DPoP proof")
+	ErrDPoPKeyMismatch  = errors.New("DPoP proof key does not match the token")
+)
+
+// Confirmation binds a token to a key, as in the RFC 7800 "cnf" claim.
+// JKT is the RFC 7638 thumbprint of the client's DPoP public key.
+type Confirmation struct {
+	JKT string `json:"jkt"`
+}
+
+// ReplayStore remembers DPoP proof ids until they are too old to be
+// accepted anyway. Claim reports false if key was already claimed.
+type ReplayStore interface {
+	Claim(ctx context.Context, key string, ttl time.Duration) bool
+}
+
+type dpopClaims struct {
+	jwt.RegisteredClaims
+	Method          string `json:"htm"`
+	URI             string `json:"htu"`
+	AccessTokenHash string `json:"ath,omitempty"`
+}
+
+// DPoP verifies RFC 9449 proofs of possession sent in the DPoP header.
+type DPoP struct {
+	replay         ReplayStore
+	window         time.Duration
+	trustedProxies []*net.IPNet
+}
+
+// NewDPoP accepts proofs issued at most window away from the current
+// time, and each proof only once. X-Forwarded-Proto is only believed on
+// requests coming from one of trustedProxies.
+func NewDPoP(replay ReplayStore, window time.Duration, trustedProxies []*net.IPNet) *DPoP {
+	return &DPoP{
+		replay:         replay,
+		window:         window,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// Verify checks the DPoP proof on r and returns the thumbprint of the key
+// that signed it. accessToken is the token presented with the proof, or
+// empty when the proof is sent to obtain one.
+func (d *DPoP) Verify(ctx context.Context, r *http.Request, accessToken string) (string, error) {
+	proofs := r.Header.Values(DPoPHeader)
+	if len(proofs) == 0 {
+		return "", ErrDPoPProofMissing
+	}
+	if len(proofs) > 1 {
+		return "", fmt.Errorf("%w: more than one proof", ErrDPoPProofInvalid)
+	}
+
+	var jwk *JWK
+	claims := &dpopClaims{}
+	_, err := jwt.ParseWithClaims(proofs[0], claims, func(t *jwt.Token) (any, error) {
+		if t.Header["typ"] != "dpop+jwt" {
+			return nil, errors.New("typ must be dpop+jwt")
+		}
+		raw, err := json.Marshal(t.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &jwk); err != nil || jwk == nil {
+			return nil, errors.New("missing jwk header")
+		}
+		return jwk.publicKey()
+	}, jwt.WithValidMethods([]string{"ES256", "EdDSA", "RS256", "PS256"}))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDPoPProofInvalid, err)
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return "", fmt.Errorf("%w: jti and iat are required", ErrDPoPProofInvalid)
+	}
+	if claims.Method != r.Method {
+		return "", fmt.Errorf("%w: htm does not match the request", ErrDPoPProofInvalid)
+	}
+	if !sameURI(claims.URI, d.requestURI(r)) {
+		return "", fmt.Errorf("%w: htu does not match the request", ErrDPoPProofInvalid)
+	}
+	if age := time.Since(claims.IssuedAt.Time); age > d.window || age < -d.window {
+		return "", fmt.Errorf("%w: iat is outside the accepted window", ErrDPoPProofInvalid)
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		if claims.AccessTokenHash != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			return "", fmt.Errorf("%w: ath does not match the access token", ErrDPoPProofInvalid)
+		}
+	}
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDPoPProofInvalid, err)
+	}
+
+	// a proof is good for one request; iat bounds how long to remember it
+	if !d.replay.Claim(ctx, thumbprint+":"+claims.ID, 2*d.window) {
+		return "", fmt.Errorf("%w: proof has already been used", ErrDPoPProofInvalid)
+	}
+
+	return thumbprint, nil
+}
+
+// requestURI is the htu a client would compute for r: the absolute URL
+// without query or fragment.
+func (d *DPoP) requestURI(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" && d.fromTrustedProxy(r) {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// fromTrustedProxy reports whether r was sent by one of the trusted
+// proxies, so its forwarding headers were set by the proxy and not the
+// client.
+func (d *DPoP) fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range d.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameURI compares htu to the request URL, ignoring case in the scheme
+// and host and any query or fragment.
+func sameURI(htu string, want string) bool {
+	got, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	expected, err := url.Parse(want)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(got.Scheme, expected.Scheme) &&
+		strings.EqualFold(got.Host, expected.Host) &&
+		got.Path == expected.Path
+}
+
+// publicKey decodes a public JWK; keys that include private material are
+// rejected.
+func (jwk *JWK) publicKey() (crypto.PublicKey, error) {
+	if jwk.D != "" {
+		return nil, errors.New("jwk must not contain a private key")
+	}
+
+	switch jwk.KeyType {
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+		// coordinates are exactly 32 bytes, so one key has one thumbprint
+		x, errX := strictBase64.DecodeString(jwk.X)
+		y, errY := strictBase64.DecodeString(jwk.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid EC key coordinates")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC key is not on the curve")
+		}
+		return key, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) > 4 {
+			return nil, errors.New("invalid RSA key")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA key must be at least 2048 bits")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+}
+
+// Thumbprint is the RFC 7638 SHA-256 thumbprint of the key: the required
+// members in lexicographic order, without whitespace.
+func (jwk *JWK) Thumbprint() (string, error) {
+	var members string
+	switch jwk.KeyType {
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Curve, jwk.X, jwk.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Curve, jwk.X)
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	default:
+		return "", fmt.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
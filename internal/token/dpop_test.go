@@ -0,0 +1,329 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// memoryReplay is a ReplayStore that never forgets.
+type memoryReplay map[string]bool
+
+func (m memoryReplay) Claim(ctx context.Context, key string, ttl time.Duration) bool {
+	if m[key] {
+		return false
+	}
+	m[key] = true
+	return true
+}
+
+const (
+	testDPoPURI   = "https://auth.example.com/api/v1/auth/refresh"
+	testDPoPToken = "v4.public.access-token"
+)
+
+func newTestDPoP(t *testing.T) *DPoP {
+	t.Helper()
+	_, proxy, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDPoP(memoryReplay{}, time.Minute, []*net.IPNet{proxy})
+}
+
+func ecJWK(key *ecdsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// signProof signs a valid proof for a POST to testDPoPURI with
+// testDPoPToken, after letting edit change its header and claims.
+func signProof(t *testing.T, key *ecdsa.PrivateKey, edit func(header map[string]any, claims *dpopClaims)) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(testDPoPToken))
+	claims := &dpopClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       rand.Text(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		Method:          http.MethodPost,
+		URI:             testDPoPURI,
+		AccessTokenHash: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}
+	proof := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	proof.Header["typ"] = "dpop+jwt"
+	proof.Header["jwk"] = ecJWK(&key.PublicKey)
+	if edit != nil {
+		edit(proof.Header, claims)
+	}
+	signed, err := proof.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// proofRequest is a POST to testDPoPURI as received behind a proxy that
+// terminates TLS.
+func proofRequest(proofs ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "http://auth.example.com/api/v1/auth/refresh", nil)
+	r.RemoteAddr = "10.0.0.5:41000"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	for _, proof := range proofs {
+		r.Header.Add(DPoPHeader, proof)
+	}
+	return r
+}
+
+func TestDPoPVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := (&JWK{
+		KeyType: "EC",
+		Curve:   "P-256",
+		X:       ecJWK(&key.PublicKey)["x"].(string),
+		Y:       ecJWK(&key.PublicKey)["y"].(string),
+	}).Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		request func(t *testing.T) *http.Request
+		token   string
+		wantErr error
+	}{
+		{
+			name:    "valid proof",
+			request: func(t *testing.T) *http.Request { return proofRequest(signProof(t, key, nil)) },
+			token:   testDPoPToken,
+		},
+		{
+			name: "valid proof without an access token",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.AccessTokenHash = ""
+				}))
+			},
+		},
+		{
+			name:    "missing proof",
+			request: func(t *testing.T) *http.Request { return proofRequest() },
+			wantErr: ErrDPoPProofMissing,
+		},
+		{
+			name: "more than one proof",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, nil), signProof(t, key, nil))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "wrong typ",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					header["typ"] = "JWT"
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "missing jwk",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					delete(header, "jwk")
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "jwk with a private key",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					jwk := ecJWK(&key.PublicKey)
+					jwk["d"] = base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32)))
+					header["jwk"] = jwk
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "EC coordinate of the wrong length",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					jwk := ecJWK(&key.PublicKey)
+					x := key.X.FillBytes(make([]byte, 33)) // same value, leading zero
+					jwk["x"] = base64.RawURLEncoding.EncodeToString(x)
+					header["jwk"] = jwk
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "jwk of another key",
+			request: func(t *testing.T) *http.Request {
+				other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					header["jwk"] = ecJWK(&other.PublicKey)
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "missing jti",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.ID = ""
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "htm does not match",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.Method = http.MethodGet
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "htu names another path",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.URI = "https://auth.example.com/api/v1/auth/logout"
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "htu ignores query and case of the host",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.URI = "https://Auth.Example.com/api/v1/auth/refresh?x=1"
+				}))
+			},
+			token: testDPoPToken,
+		},
+		{
+			name: "X-Forwarded-Proto from an untrusted client",
+			request: func(t *testing.T) *http.Request {
+				r := proofRequest(signProof(t, key, nil))
+				r.RemoteAddr = "203.0.113.9:41000"
+				return r
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "iat too old",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "iat in the future",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(2 * time.Minute))
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "missing iat",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.IssuedAt = nil
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name:    "ath for another token",
+			request: func(t *testing.T) *http.Request { return proofRequest(signProof(t, key, nil)) },
+			token:   "v4.public.other-token",
+			wantErr: ErrDPoPProofInvalid,
+		},
+		{
+			name: "ath missing",
+			request: func(t *testing.T) *http.Request {
+				return proofRequest(signProof(t, key, func(header map[string]any, claims *dpopClaims) {
+					claims.AccessTokenHash = ""
+				}))
+			},
+			token:   testDPoPToken,
+			wantErr: ErrDPoPProofInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestDPoP(t).Verify(context.Background(), tt.request(t), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got != thumbprint {
+				t.Fatalf("thumbprint = %s, want %s", got, thumbprint)
+			}
+		})
+	}
+}
+
+func TestDPoPProofIsUsedOnce(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dpop := newTestDPoP(t)
+	proof := signProof(t, key, nil)
+
+	if _, err := dpop.Verify(context.Background(), proofRequest(proof), testDPoPToken); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := dpop.Verify(context.Background(), proofRequest(proof), testDPoPToken); !errors.Is(err, ErrDPoPProofInvalid) {
+		t.Fatalf("replayed proof: err = %v, want %v", err, ErrDPoPProofInvalid)
+	}
+}
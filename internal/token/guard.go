@@ -0,0 +1,186 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval throttles the last-seen writes made while
+// verifying tokens; idle timeouts are only as precise as this.
+const sessionTouchInterval = time.Minute
+
+// Stores are the server-side lookups every Maker checks tokens against,
+// whatever the token format. Any of them may be nil.
+type Stores struct {
+	Users       UserRepository
+	Sessions    SessionRepository
+	Revocations RevocationStore
+}
+
+// SessionActivity is what the session limits are checked against.
+type SessionActivity struct {
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// SessionLimits end a session IdleTimeout after it was last used, or
+// Lifetime after login however active it is. Zero disables a limit.
+type SessionLimits struct {
+	IdleTimeout time.Duration
+	Lifetime    time.Duration
+}
+
+// Options configure the claims every Maker stamps on new tokens and
+// requires on incoming ones. Empty Issuer or Audience disables that check.
+// ExchangeAudiences are the other services tokens may be exchanged for;
+// only IntrospectToken accepts them, so those services can introspect.
+type Options struct {
+	Issuer            string
+	Audience          string
+	ExchangeAudiences []string
+	SessionLimits     SessionLimits
+	Stores            Stores
+}
+
+// guard runs the checks shared by every Maker on top of Options.
+type guard struct {
+	opts Options
+}
+
+// newPayload builds the payload for a new token, stamped with this
+// service's issuer and, unless params name another, its audience.
+func (g guard) newPayload(ctx context.Context, params *TokenParams, duration time.Duration, tokenType string) (*Payload, error) {
+	payload, err := NewPayload(params, g.sessionVersion(ctx, params.UserID), duration, tokenType)
+	if err != nil {
+		return nil, err
+	}
+	payload.Issuer = g.opts.Issuer
+	payload.Audience = g.opts.Audience
+	if params.Audience != "" {
+		payload.Audience = params.Audience
+	}
+	return payload, nil
+}
+
+// acceptsAudience reports whether a token for audience is meant for this
+// service or, when exchanged is set, for one it exchanges tokens with.
+func (g guard) acceptsAudience(audience string, exchanged bool) bool {
+	if g.opts.Audience == "" || audience == g.opts.Audience {
+		return true
+	}
+	if !exchanged {
+		return false
+	}
+	for _, aud := range g.opts.ExchangeAudiences {
+		if audience == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func (g guard) sessionVersion(ctx context.Context, userID int64) int {
+	sv := 1
+	if g.opts.Stores.Users != nil {
+		if v, err := g.opts.Stores.Users.GetSessionVersion(ctx, userID); err == nil {
+			sv = v
+		}
+	}
+	return sv
+}
+
+// checkOrigin makes sure tokens from another environment are not
+// replayed here. Tokens exchanged for another service only pass when
+// exchanged is set.
+func (g guard) checkOrigin(payload *Payload, exchanged bool) error {
+	if g.opts.Issuer != "" && payload.Issuer != g.opts.Issuer {
+		return ErrInvalidIssuer
+	}
+	if !g.acceptsAudience(payload.Audience, exchanged) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// check runs the claim and revocation checks on a payload whose
+// signature or encryption has already been verified.
+func (g guard) check(ctx context.Context, payload *Payload, exchanged bool) error {
+	err := payload.Valid()
+	if err != nil {
+		return err
+	}
+
+	// check if token is expired
+	if time.Now().After(payload.ExpiredAt) {
+		return ErrExpiredToken
+	}
+
+	if err := g.checkOrigin(payload, exchanged); err != nil {
+		return err
+	}
+
+	if g.opts.Stores.Users != nil {
+		currentSessionVersion, err := g.opts.Stores.Users.GetSessionVersion(ctx, payload.UserId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTokenRevoked // User deleted
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRevocationCheck, err)
+		}
+		if currentSessionVersion != payload.SessionVersion {
+			return ErrTokenRevoked // Session invalidated
+		}
+	}
+
+	if g.opts.Stores.Sessions != nil && payload.SessionID != "" {
+		if err := g.checkSession(ctx, payload.SessionID); err != nil {
+			return err
+		}
+	}
+
+	// fail closed: a revoked token must not come back while the store
+	// is unreachable
+	if g.opts.Stores.Revocations != nil {
+		revoked, err := g.opts.Stores.Revocations.IsRevoked(ctx, payload.ID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRevocationCheck, err)
+		}
+		if revoked {
+			return ErrTokenRevoked // Revoked individually
+		}
+	}
+
+	return nil
+}
+
+// checkSession rejects tokens whose device session was logged out, sat
+// idle too long or outlived its lifetime, and records the session as used.
+// When the session can not be loaded the token is rejected as well.
+func (g guard) checkSession(ctx context.Context, sessionID string) error {
+	activity, err := g.opts.Stores.Sessions.GetSessionActivity(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRevocationCheck, err)
+	}
+	if activity == nil || activity.RevokedAt != nil {
+		return ErrTokenRevoked // Device session logged out
+	}
+
+	now := time.Now()
+	limits := g.opts.SessionLimits
+	if limits.Lifetime > 0 && now.After(activity.CreatedAt.Add(limits.Lifetime)) {
+		return ErrSessionExpired
+	}
+	if limits.IdleTimeout > 0 && now.After(activity.LastSeenAt.Add(limits.IdleTimeout)) {
+		return ErrSessionExpired
+	}
+
+	if now.Sub(activity.LastSeenAt) > sessionTouchInterval {
+		_ = g.opts.Stores.Sessions.MarkSessionSeen(ctx, sessionID)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+)
+
+// JWK is a public key in RFC 7517 form as served from the JWKS endpoint.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	D         string `json:"d,omitempty"` // only ever set on keys to reject
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKSProvider is implemented by makers that publish a JSON Web Key Set.
+type JWKSProvider interface {
+	JWKS() []JWK
+}
+
+// jwtClaims maps Payload onto registered JWT claims; the remaining fields
+// keep the names Payload uses in PASETO tokens.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Email          string        `json:"email"`
+	RoleId         string        `json:"role_id"`
+	Role           string        `json:"role,omitempty"`
+	Scope          string        `json:"scope,omitempty"`
+	SessionVersion int           `json:"session_version"`
+	SessionID      string        `json:"sid,omitempty"`
+	TokenType      string        `json:"token_type"`
+	Actor          *Actor        `json:"act,omitempty"`
+	Confirmation   *Confirmation `json:"cnf,omitempty"`
+}
+
+// JWT issues signed JSON Web Tokens for consumers that do not speak PASETO.
+type JWT struct {
+	guard
+	method     jwt.SigningMethod
+	keyID      string
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+func NewJWT(algorithm string, keyID string, privateKeyPEM []byte, opts Options) (Maker, error) {
+	maker := &JWT{
+		guard: guard{opts: opts},
+		keyID: keyID,
+	}
+
+	switch algorithm {
+	case AlgorithmEdDSA:
+		key, err := jwt.ParseEdPrivateKeyFromPEM(privateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EdDSA private key: %w", err)
+		}
+		maker.method = jwt.SigningMethodEdDSA
+		maker.privateKey = key.(ed25519.PrivateKey)
+	case AlgorithmRS256:
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RS256 private key: %w", err)
+		}
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("invalid RS256 private key: must be at least 2048 bits")
+		}
+		maker.method = jwt.SigningMethodRS256
+		maker.privateKey = key
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", algorithm)
+	}
+	maker.publicKey = maker.privateKey.Public()
+
+	if maker.keyID == "" {
+		switch key := maker.publicKey.(type) {
+		case ed25519.PublicKey:
+			maker.keyID = KeyIDFor(key)
+		case *rsa.PublicKey:
+			maker.keyID = KeyIDFor(key.N.Bytes())
+		}
+	}
+
+	return maker, nil
+}
+
+func (maker *JWT) CreateToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "access")
+}
+
+func (maker *JWT) CreateRefreshToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "refresh")
+}
+
+func (maker *JWT) CreateMFAPendingToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "mfa_pending")
+}
+
+func (maker *JWT) createToken(ctx context.Context, params *TokenParams, duration time.Duration, tokenType string) (string, *Payload, error) {
+	payload, err := maker.newPayload(ctx, params, duration, tokenType)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create payload: %w", err)
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, claimsFromPayload(payload))
+	jwtToken.Header["kid"] = maker.keyID
+
+	token, err := jwtToken.SignedString(maker.privateKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign %s token: %w", tokenType, err)
+	}
+
+	return token, payload, nil
+}
+
+func (maker *JWT) VerifyToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.check(ctx, payload, false); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *JWT) IntrospectToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.check(ctx, payload, true); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *JWT) DecodeToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.checkOrigin(payload, false); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *JWT) decode(token string, opts ...jwt.ParserOption) (*Payload, error) {
+	opts = append(opts, jwt.WithValidMethods([]string{maker.method.Alg()}))
+	claims := &jwtClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if kid, _ := t.Header["kid"].(string); kid != maker.keyID {
+			return nil, ErrInvalidToken
+		}
+		return maker.publicKey, nil
+	}, opts...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		if errors.Is(err, jwt.ErrTokenNotValidYet) {
+			return nil, ErrNotYetValid
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := claims.payload()
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+func (maker *JWT) JWKS() []JWK {
+	key := JWK{
+		Use:       "sig",
+		KeyID:     maker.keyID,
+		Algorithm: maker.method.Alg(),
+	}
+
+	switch pub := maker.publicKey.(type) {
+	case ed25519.PublicKey:
+		key.KeyType = "OKP"
+		key.Curve = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		key.KeyType = "RSA"
+		key.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+
+	return []JWK{key}
+}
+
+func claimsFromPayload(payload *Payload) *jwtClaims {
+	claims := &jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID,
+			Issuer:    payload.Issuer,
+			Subject:   strconv.FormatInt(payload.UserId, 10),
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			NotBefore: jwt.NewNumericDate(payload.NotBefore),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+		Email:          payload.Email,
+		RoleId:         payload.RoleId,
+		Role:           payload.Role,
+		Scope:          strings.Join(payload.Scopes, " "),
+		SessionVersion: payload.SessionVersion,
+		SessionID:      payload.SessionID,
+		TokenType:      payload.TokenType,
+		Actor:          payload.Actor,
+		Confirmation:   payload.Confirmation,
+	}
+	if payload.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{payload.Audience}
+	}
+	return claims
+}
+
+func (claims *jwtClaims) payload() (*Payload, error) {
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+	if len(claims.Audience) > 1 {
+		return nil, ErrInvalidAudience
+	}
+
+	payload := &Payload{
+		ID:             claims.ID,
+		Issuer:         claims.Issuer,
+		UserId:         userId,
+		Email:          claims.Email,
+		RoleId:         claims.RoleId,
+		Role:           claims.Role,
+		Scopes:         strings.Fields(claims.Scope),
+		SessionVersion: claims.SessionVersion,
+		SessionID:      claims.SessionID,
+		TokenType:      claims.TokenType,
+		Actor:          claims.Actor,
+		Confirmation:   claims.Confirmation,
+		IssuedAt:       claims.IssuedAt.Time,
+		ExpiredAt:      claims.ExpiresAt.Time,
+	}
+	if len(claims.Audience) == 1 {
+		payload.Audience = claims.Audience[0]
+	}
+	if claims.NotBefore != nil {
+		payload.NotBefore = claims.NotBefore.Time
+	}
+	return payload, nil
+}
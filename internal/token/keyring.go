@@ -0,0 +1,188 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// DefaultKeyID names the key built from paseto.key. Tokens minted before
+// key ids existed have no footer and are verified with it.
+const DefaultKeyID = "default"
+
+var ErrNoActiveKey = errors.New("keyring has no active key")
+
+// SymmetricKey is one keyring entry. It signs new tokens from NotBefore
+// onwards and verifies tokens until NotAfter.
+type SymmetricKey struct {
+	ID        string     `json:"id"`
+	Key       string     `json:"key"`
+	NotBefore time.Time  `json:"not_before"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+}
+
+func (k SymmetricKey) bytes() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil || len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("key %q must be a base64 encoded %d-byte key", k.ID, chacha20poly1305.KeySize)
+	}
+	return key, nil
+}
+
+func (k SymmetricKey) retired(now time.Time) bool {
+	return k.NotAfter != nil && !now.Before(*k.NotAfter)
+}
+
+// Keyring holds every symmetric key that may still verify tokens.
+type Keyring struct {
+	mu   sync.RWMutex
+	path string
+	keys []SymmetricKey
+}
+
+// NewStaticKeyring wraps the single paseto.key in a keyring that never rotates.
+func NewStaticKeyring(symmetricKey string) (*Keyring, error) {
+	if len(symmetricKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	}
+	return &Keyring{
+		keys: []SymmetricKey{{
+			ID:  DefaultKeyID,
+			Key: base64.StdEncoding.EncodeToString([]byte(symmetricKey)),
+		}},
+	}, nil
+}
+
+// LoadKeyring reads a keyring file written by the keys rotate command.
+func LoadKeyring(path string) (*Keyring, error) {
+	keyring := &Keyring{path: path}
+	if err := keyring.Reload(); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// Reload re-reads the keyring file so keys added by a rotation elsewhere
+// are picked up without a restart.
+func (k *Keyring) Reload() error {
+	if k.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return fmt.Errorf("cannot read keyring: %w", err)
+	}
+	var keys []SymmetricKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("cannot parse keyring: %w", err)
+	}
+	for _, key := range keys {
+		if _, err := key.bytes(); err != nil {
+			return err
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+// Save writes the keyring back to the file it was loaded from.
+func (k *Keyring) Save() error {
+	k.mu.RLock()
+	data, err := json.MarshalIndent(k.keys, "", "  ")
+	k.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, data, 0600)
+}
+
+// Current returns the newest key allowed to sign at now.
+func (k *Keyring) Current(now time.Time) (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var current *SymmetricKey
+	for i := range k.keys {
+		key := &k.keys[i]
+		if now.Before(key.NotBefore) || key.retired(now) {
+			continue
+		}
+		if current == nil || key.NotBefore.After(current.NotBefore) {
+			current = key
+		}
+	}
+	if current == nil {
+		return "", nil, ErrNoActiveKey
+	}
+	secret, err := current.bytes()
+	return current.ID, secret, err
+}
+
+// Lookup returns the key with id if it may still verify tokens at now.
+func (k *Keyring) Lookup(id string, now time.Time) ([]byte, error) {
+	if id == "" {
+		id = DefaultKeyID
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == id && !key.retired(now) {
+			return key.bytes()
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+// Rotate adds a key that starts signing at activateAt, schedules every
+// older key to stop verifying retireAfter later, and drops keys that are
+// already retired.
+func (k *Keyring) Rotate(now time.Time, activateAt time.Time, retireAfter time.Duration) (SymmetricKey, error) {
+	secret := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return SymmetricKey{}, err
+	}
+	added := SymmetricKey{
+		ID:        uuid.NewString(),
+		Key:       base64.StdEncoding.EncodeToString(secret),
+		NotBefore: activateAt,
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	retireAt := activateAt.Add(retireAfter)
+	kept := make([]SymmetricKey, 0, len(k.keys)+1)
+	for _, key := range k.keys {
+		if key.retired(now) {
+			continue
+		}
+		if key.NotAfter == nil || key.NotAfter.After(retireAt) {
+			key.NotAfter = &retireAt
+		}
+		kept = append(kept, key)
+	}
+	kept = append(kept, added)
+	sort.Slice(kept, func(i, j int) bool { return kept[i].NotBefore.Before(kept[j].NotBefore) })
+	k.keys = kept
+
+	return added, nil
+}
+
+// NewKeyringFile starts a keyring at path, seeded with the given keys.
+func NewKeyringFile(path string, keys ...SymmetricKey) *Keyring {
+	return &Keyring{path: path, keys: keys}
+}
@@ -0,0 +1,133 @@
+package token
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSymmetricKey = "this is a 32-byte key for Paseto"
+
+func keyIDOf(t *testing.T, token string) string {
+	t.Helper()
+	footerBytes, err := tokenFooter(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var footer Footer
+	if err := json.Unmarshal(footerBytes, &footer); err != nil {
+		t.Fatal(err)
+	}
+	return footer.KeyID
+}
+
+func TestKeyringRotationKeepsOldTokens(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keyring.json")
+
+	// the keyring as the first rotation starts it, from paseto.key
+	seeded := NewKeyringFile(path, SymmetricKey{
+		ID:  DefaultKeyID,
+		Key: base64.StdEncoding.EncodeToString([]byte(testSymmetricKey)),
+	})
+	if err := seeded.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	running, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maker, err := NewPaseto(VersionV4, running, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _, err := maker.CreateToken(ctx, &TokenParams{UserID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another process rotates, the running instance reloads
+	now := time.Now()
+	rotated, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := rotated.Rotate(now, now, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rotated.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := running.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, _, err := maker.CreateToken(ctx, &TokenParams{UserID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keyIDOf(t, newToken); got != added.ID {
+		t.Fatalf("new token signed with key %q, want %q", got, added.ID)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := maker.VerifyToken(ctx, token); err != nil {
+			t.Fatalf("VerifyToken(%s token): %v", name, err)
+		}
+	}
+
+	// the old key stops verifying once retired
+	if _, err := running.Lookup(DefaultKeyID, now.Add(time.Hour)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Lookup of retired key: err = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := running.Lookup(added.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Lookup of current key: %v", err)
+	}
+}
+
+func TestKeyringRejectsUnknownKeyID(t *testing.T) {
+	ctx := context.Background()
+	keyring, err := NewStaticKeyring(testSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maker, err := NewPaseto(VersionV4, keyring, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same key material under an id this keyring does not hold
+	other := NewKeyringFile("", SymmetricKey{
+		ID:        "unknown",
+		Key:       base64.StdEncoding.EncodeToString([]byte(testSymmetricKey)),
+		NotBefore: time.Now().Add(-time.Minute),
+	})
+	otherMaker, err := NewPaseto(VersionV4, other, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := otherMaker.CreateToken(ctx, &TokenParams{UserID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := maker.VerifyToken(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("VerifyToken: err = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestKeyringReloadRejectsBadKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := os.WriteFile(path, []byte(`[{"id":"short","key":"c2hvcnQ="}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeyring(path); err == nil {
+		t.Fatal("loaded a keyring with a 5-byte key")
+	}
+}
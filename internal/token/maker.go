@@ -5,8 +5,35 @@ import (
 	"time"
 )
 
+// TokenParams carries the claims a Maker embeds in a new token.
+type TokenParams struct {
+	UserID    int64
+	Email     string
+	RoleId    string
+	Role      string
+	Scopes    []string
+	SessionID string
+	Actor     *Actor
+	Audience  string // overrides the configured audience, for token exchange
+
+	Confirmation *Confirmation // binds the token to a DPoP key
+}
+
 type Maker interface {
-	CreateToken(userID int64, email string, roleId string, duration time.Duration) (string, error)
-	CreateRefreshToken(userID int64, email string, roleId string, duration time.Duration) (string, error)
+	CreateToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error)
+	CreateRefreshToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error)
+	// CreateMFAPendingToken proves the password step of a two-factor
+	// login; it is only accepted by the second step.
+	CreateMFAPendingToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error)
+	// VerifyToken only accepts tokens for this service's own audience.
 	VerifyToken(ctx context.Context, token string) (*Payload, error)
+	// IntrospectToken also accepts tokens exchanged for one of the
+	// ExchangeAudiences. It exists to answer introspection and revocation
+	// for those services; never authorize or mint tokens with its result.
+	IntrospectToken(ctx context.Context, token string) (*Payload, error)
+	// DecodeToken checks that token is authentic and was issued for this
+	// service, but not whether it is still valid. It exists to match an
+	// expired access token against its refresh token; never authorize
+	// with its result.
+	DecodeToken(ctx context.Context, token string) (*Payload, error)
 }
@@ -2,99 +2,175 @@ package token
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/o1egl/paseto"
-	"golang.org/x/crypto/chacha20poly1305"
 )
 
+// Paseto issues local (symmetric) tokens. New tokens use the configured
+// version and the keyring's current key, named in the footer; VerifyToken
+// accepts both v2.local and v4.local so sessions survive a switch between
+// them.
 type Paseto struct {
-	paseto         *paseto.V2
-	symmetric      []byte
-	userRepository UserRepository
+	guard
+	paseto  *paseto.V2
+	version string
+	keyring *Keyring
 }
 
 type UserRepository interface {
 	GetSessionVersion(ctx context.Context, userID int64) (int, error)
 }
 
-func NewPaseto(symmetricKey string, userRepository UserRepository) (Maker, error) {
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+type SessionRepository interface {
+	GetSessionActivity(ctx context.Context, sessionID string) (*SessionActivity, error)
+	MarkSessionSeen(ctx context.Context, sessionID string) error
+}
+
+type RevocationStore interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+func NewPaseto(version string, keyring *Keyring, opts Options) (Maker, error) {
+	if version != VersionV2 && version != VersionV4 {
+		return nil, fmt.Errorf("unsupported paseto version %q for local purpose", version)
+	}
+	if _, _, err := keyring.Current(time.Now()); err != nil {
+		return nil, err
 	}
 
 	maker := &Paseto{
-		paseto:         paseto.NewV2(),
-		symmetric:      []byte(symmetricKey),
-		userRepository: userRepository,
+		guard:   guard{opts: opts},
+		paseto:  paseto.NewV2(),
+		version: version,
+		keyring: keyring,
 	}
 
 	return maker, nil
 }
 
-func (maker *Paseto) CreateToken(userID int64, email string, roleId string, duration time.Duration) (string, error) {
-	sv := 1
-	if maker.userRepository != nil {
-		if v, err := maker.userRepository.GetSessionVersion(context.Background(), userID); err == nil {
-			sv = v
-		}
-	}
-	payload, err := NewPayload(userID, email, roleId, sv, duration, "access")
+func (maker *Paseto) CreateToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "access")
+}
+
+func (maker *Paseto) CreateRefreshToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "refresh")
+}
+
+func (maker *Paseto) CreateMFAPendingToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "mfa_pending")
+}
+
+func (maker *Paseto) createToken(ctx context.Context, params *TokenParams, duration time.Duration, tokenType string) (string, *Payload, error) {
+	payload, err := maker.newPayload(ctx, params, duration, tokenType)
 	if err != nil {
-		return "", fmt.Errorf("failed to create payload: %w", err)
+		return "", nil, fmt.Errorf("failed to create payload: %w", err)
 	}
 
-	token, err := maker.paseto.Encrypt(maker.symmetric, payload, nil)
+	keyID, key, err := maker.keyring.Current(time.Now())
 	if err != nil {
-		return "", fmt.Errorf("failed to encrypt refresh token: %w", err)
+		return "", nil, err
 	}
+	footer := Footer{KeyID: keyID}
 
-	return token, nil
-}
-
-func (maker *Paseto) CreateRefreshToken(userID int64, email string, roleId string, duration time.Duration) (string, error) {
-	sv := 1
-	if maker.userRepository != nil {
-		if v, err := maker.userRepository.GetSessionVersion(context.Background(), userID); err == nil {
-			sv = v
+	if maker.version == VersionV2 {
+		token, err := maker.paseto.Encrypt(key, payload, footer)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encrypt %s token: %w", tokenType, err)
 		}
+		return token, payload, nil
 	}
-	payload, err := NewPayload(userID, email, roleId, sv, duration, "refresh")
+
+	message, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to create payload: %w", err)
+		return "", nil, fmt.Errorf("failed to encrypt %s token: %w", tokenType, err)
 	}
-
-	token, err := maker.paseto.Encrypt(maker.symmetric, payload, nil)
+	footerBytes, err := json.Marshal(footer)
 	if err != nil {
-		return "", fmt.Errorf("failed to encrypt refresh token: %w", err)
+		return "", nil, fmt.Errorf("failed to encrypt %s token: %w", tokenType, err)
+	}
+	token, err := v4Encrypt(key, message, footerBytes, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt %s token: %w", tokenType, err)
 	}
 
-	return token, nil
+	return token, payload, nil
 }
 
 func (maker *Paseto) VerifyToken(ctx context.Context, token string) (*Payload, error) {
-	payload := &Payload{}
-	err := maker.paseto.Decrypt(token, maker.symmetric, payload, nil)
+	payload, err := maker.decode(token)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, err
+	}
+
+	if err := maker.check(ctx, payload, false); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *Paseto) IntrospectToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.check(ctx, payload, true); err != nil {
+		return nil, err
 	}
 
-	err = payload.Valid()
+	return payload, nil
+}
+
+func (maker *Paseto) DecodeToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
 	if err != nil {
 		return nil, err
 	}
 
-	// check if token is expired
-	if time.Now().After(payload.ExpiredAt) {
-		return nil, ErrExpiredToken
+	if err := maker.checkOrigin(payload, false); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *Paseto) decode(token string) (*Payload, error) {
+	footerBytes, err := tokenFooter(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var footer Footer
+	if len(footerBytes) > 0 {
+		if err := json.Unmarshal(footerBytes, &footer); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+	key, err := maker.keyring.Lookup(footer.KeyID, time.Now())
+	if err != nil {
+		return nil, ErrInvalidToken
 	}
 
-	if maker.userRepository != nil {
-		currentSessionVersion, err := maker.userRepository.GetSessionVersion(ctx, payload.UserId)
-		if err == nil && currentSessionVersion != payload.SessionVersion {
-			return nil, ErrTokenRevoked // Session invalidated
+	payload := &Payload{}
+	switch {
+	case strings.HasPrefix(token, "v2.local."):
+		if err := maker.paseto.Decrypt(token, key, payload, nil); err != nil {
+			return nil, ErrInvalidToken
 		}
+	case strings.HasPrefix(token, headerV4Local):
+		message, err := v4Decrypt(token, key, nil)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		if err := json.Unmarshal(message, payload); err != nil {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
 	}
 
 	return payload, nil
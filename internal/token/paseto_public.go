@@ -0,0 +1,212 @@
+package token
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+const (
+	VersionV2 = "v2"
+	VersionV4 = "v4"
+)
+
+// Footer is the unencrypted trailer of a token. It names the key that
+// signed the token so verifiers can pick the right public key.
+type Footer struct {
+	KeyID string `json:"kid"`
+}
+
+// PublicKey is a verification key as published on the well-known endpoint.
+type PublicKey struct {
+	KeyID     string `json:"kid"`
+	Version   string `json:"version"`
+	Purpose   string `json:"purpose"`
+	PublicKey string `json:"public_key"`
+}
+
+// PublicKeyProvider is implemented by makers whose tokens can be verified
+// without the secret used to mint them.
+type PublicKeyProvider interface {
+	PublicKeys() []PublicKey
+}
+
+// PasetoPublic signs tokens with Ed25519 (v2.public or v4.public), so other
+// services only need the published public key to verify them.
+type PasetoPublic struct {
+	guard
+	paseto     *paseto.V2
+	version    string
+	keyID      string
+	privateKey ed25519.PrivateKey
+	publicKeys map[string]ed25519.PublicKey
+}
+
+func NewPasetoPublic(version string, keyID string, privateKeyHex string, opts Options) (Maker, error) {
+	if version != VersionV2 && version != VersionV4 {
+		return nil, fmt.Errorf("unsupported paseto version %q for public purpose", version)
+	}
+
+	seed, err := hex.DecodeString(privateKeyHex)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key: must be a hex encoded %d-byte Ed25519 seed", ed25519.SeedSize)
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	if keyID == "" {
+		keyID = KeyIDFor(publicKey)
+	}
+
+	maker := &PasetoPublic{
+		guard:      guard{opts: opts},
+		paseto:     paseto.NewV2(),
+		version:    version,
+		keyID:      keyID,
+		privateKey: privateKey,
+		publicKeys: map[string]ed25519.PublicKey{keyID: publicKey},
+	}
+
+	return maker, nil
+}
+
+// KeyIDFor derives a stable key id from the public key when none is configured.
+func KeyIDFor(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (maker *PasetoPublic) CreateToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "access")
+}
+
+func (maker *PasetoPublic) CreateRefreshToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "refresh")
+}
+
+func (maker *PasetoPublic) CreateMFAPendingToken(ctx context.Context, params *TokenParams, duration time.Duration) (string, *Payload, error) {
+	return maker.createToken(ctx, params, duration, "mfa_pending")
+}
+
+func (maker *PasetoPublic) createToken(ctx context.Context, params *TokenParams, duration time.Duration, tokenType string) (string, *Payload, error) {
+	payload, err := maker.newPayload(ctx, params, duration, tokenType)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create payload: %w", err)
+	}
+
+	footer := Footer{KeyID: maker.keyID}
+
+	if maker.version == VersionV2 {
+		token, err := maker.paseto.Sign(maker.privateKey, payload, footer)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to sign %s token: %w", tokenType, err)
+		}
+		return token, payload, nil
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign %s token: %w", tokenType, err)
+	}
+	footerBytes, err := json.Marshal(footer)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign %s token: %w", tokenType, err)
+	}
+
+	return v4Sign(maker.privateKey, message, footerBytes, nil), payload, nil
+}
+
+func (maker *PasetoPublic) VerifyToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.check(ctx, payload, false); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *PasetoPublic) IntrospectToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.check(ctx, payload, true); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *PasetoPublic) DecodeToken(ctx context.Context, token string) (*Payload, error) {
+	payload, err := maker.decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := maker.checkOrigin(payload, false); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *PasetoPublic) decode(token string) (*Payload, error) {
+	footerBytes, err := tokenFooter(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var footer Footer
+	if err := json.Unmarshal(footerBytes, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+	publicKey, ok := maker.publicKeys[footer.KeyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	switch {
+	case strings.HasPrefix(token, "v2.public."):
+		if err := maker.paseto.Verify(token, publicKey, payload, nil); err != nil {
+			return nil, ErrInvalidToken
+		}
+	case strings.HasPrefix(token, headerV4Public):
+		message, err := v4Verify(token, publicKey, nil)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		if err := json.Unmarshal(message, payload); err != nil {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+func (maker *PasetoPublic) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(maker.publicKeys))
+	for kid, key := range maker.publicKeys {
+		keys = append(keys, PublicKey{
+			KeyID:     kid,
+			Version:   maker.version,
+			Purpose:   "public",
+			PublicKey: base64.RawURLEncoding.EncodeToString(key),
+		})
+	}
+	return keys
+}
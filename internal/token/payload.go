@@ -2,47 +2,102 @@ package token
 
 import (
 	"errors"
-	"fmt"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidToken = errors.New("token is invalid")
-	ErrExpiredToken = errors.New("token has expired")
-	ErrTokenRevoked = errors.New("token has been revoked")
+	ErrInvalidToken    = errors.New("token is invalid")
+	ErrExpiredToken    = errors.New("token has expired")
+	ErrTokenRevoked    = errors.New("token has been revoked")
+	ErrNotYetValid     = errors.New("token is not valid yet")
+	ErrInvalidIssuer   = errors.New("token issuer is not accepted")
+	ErrInvalidAudience = errors.New("token audience is not accepted")
+	ErrSessionExpired  = errors.New("session has expired, please login again")
+	ErrRevocationCheck = errors.New("cannot check whether the token was revoked")
 )
 
+// Actor identifies who is really behind a token issued on another
+// user's behalf, as in the RFC 8693 "act" claim. Tokens issued here name at
+// most one actor, since tokens that already have one are not exchanged;
+// the nested Actor is only filled in by other issuers, the current actor
+// outermost and prior ones nested inside it.
+type Actor struct {
+	Subject string `json:"sub"`
+	Actor   *Actor `json:"act,omitempty"`
+}
+
 type Payload struct {
-	ID             string    `json:"id"`
-	UserId         int64     `json:"user_id"`
-	Email          string    `json:"email"`
-	RoleId         string    `json:"role_id"`
-	SessionVersion int       `json:"session_version"`
-	TokenType      string    `json:"token_type"`
-	IssuedAt       time.Time `json:"iat"`
-	ExpiredAt      time.Time `json:"exp"`
+	ID             string        `json:"id"`
+	Issuer         string        `json:"iss,omitempty"`
+	Audience       string        `json:"aud,omitempty"`
+	UserId         int64         `json:"user_id"`
+	Email          string        `json:"email"`
+	RoleId         string        `json:"role_id"`
+	Role           string        `json:"role,omitempty"`
+	Scopes         []string      `json:"scopes,omitempty"`
+	SessionVersion int           `json:"session_version"`
+	SessionID      string        `json:"sid,omitempty"`
+	TokenType      string        `json:"token_type"`
+	Actor          *Actor        `json:"act,omitempty"`
+	Confirmation   *Confirmation `json:"cnf,omitempty"`
+	IssuedAt       time.Time     `json:"iat"`
+	NotBefore      time.Time     `json:"nbf"`
+	ExpiredAt      time.Time     `json:"exp"`
 }
 
-func NewPayload(userId int64, email, roleId string, sessionVersion int, duration time.Duration, tokenType string) (*Payload, error) {
-	tokenId := fmt.Sprintf("token_%s_%d_%d", tokenType, userId, time.Now().Unix())
+func NewPayload(params *TokenParams, sessionVersion int, duration time.Duration, tokenType string) (*Payload, error) {
+	// random (v4) so ids cannot be guessed or collide within a second
+	tokenId, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 
 	payload := &Payload{
-		ID:             tokenId,
-		UserId:         userId,
-		Email:          email,
-		RoleId:         roleId,
+		ID:             tokenId.String(),
+		UserId:         params.UserID,
+		Email:          params.Email,
+		RoleId:         params.RoleId,
+		Role:           params.Role,
+		Scopes:         params.Scopes,
 		SessionVersion: sessionVersion,
+		SessionID:      params.SessionID,
 		TokenType:      tokenType,
-		IssuedAt:       time.Now(),
-		ExpiredAt:      time.Now().Add(duration),
+		Actor:          params.Actor,
+		Confirmation:   params.Confirmation,
+		IssuedAt:       now,
+		NotBefore:      now,
+		ExpiredAt:      now.Add(duration),
 	}
 
 	return payload, nil
 }
 
 func (payload *Payload) Valid() error {
-	if time.Now().After(payload.ExpiredAt) {
+	now := time.Now()
+	if now.After(payload.ExpiredAt) {
 		return ErrExpiredToken
 	}
+	if now.Before(payload.NotBefore) {
+		return ErrNotYetValid
+	}
 	return nil
 }
+
+func (payload *Payload) HasScope(scope string) bool {
+	for _, s := range payload.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsImpersonated reports whether the token was issued to someone acting as
+// the subject rather than to the subject itself.
+func (payload *Payload) IsImpersonated() bool {
+	return payload.Actor != nil
+}
@@ -7,6 +7,8 @@
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and PASETO token.
+// @securityDefinitions.basic BasicAuth
+// @description OAuth client credentials (client_id:client_secret).
 
 package main
 
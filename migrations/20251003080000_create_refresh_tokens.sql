@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE refresh_tokens (
+  jti VARCHAR(64) PRIMARY KEY,
+  family_id VARCHAR(64) NOT NULL,
+  parent_jti VARCHAR(64) NULL,
+  user_id BIGINT NOT NULL,
+  consumed BOOLEAN NOT NULL DEFAULT FALSE,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_refresh_tokens_family_id (family_id),
+  FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE oauth_clients (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  client_id VARCHAR(100) UNIQUE NOT NULL,
+  client_secret VARCHAR(255) NOT NULL,
+  name VARCHAR(100),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE revoked_tokens (
+  jti VARCHAR(64) PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_revoked_tokens_expires_at (expires_at)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE permissions (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  name VARCHAR(100) UNIQUE NOT NULL
+);
+
+CREATE TABLE role_permissions (
+  role_id BIGINT NOT NULL,
+  permission_id BIGINT NOT NULL,
+  PRIMARY KEY (role_id, permission_id),
+  FOREIGN KEY (role_id) REFERENCES roles(id),
+  FOREIGN KEY (permission_id) REFERENCES permissions(id)
+);
+
+INSERT INTO roles (id, name) VALUES (2, 'user');
+
+INSERT INTO permissions (name) VALUES ('users:read'), ('users:write'), ('users:delete'), ('profile:read');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT 1, id FROM permissions;
+
+-- self-service: users read their own profile
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT 2, id FROM permissions WHERE name = 'profile:read';
+
+-- +goose Down
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DELETE FROM roles WHERE id = 2;
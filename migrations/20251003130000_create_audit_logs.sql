@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE audit_logs (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  action VARCHAR(100) NOT NULL,
+  actor_id BIGINT NULL,
+  subject_id BIGINT NULL,
+  ip_address VARCHAR(45),
+  user_agent VARCHAR(255),
+  detail VARCHAR(255),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_audit_logs_actor_id (actor_id),
+  INDEX idx_audit_logs_subject_id (subject_id)
+);
+
+INSERT INTO permissions (name) VALUES ('users:impersonate');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT 1, id FROM permissions WHERE name = 'users:impersonate';
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_id = (SELECT id FROM permissions WHERE name = 'users:impersonate');
+DELETE FROM permissions WHERE name = 'users:impersonate';
+DROP TABLE IF EXISTS audit_logs;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE user_totp (
+  user_id BIGINT PRIMARY KEY,
+  secret VARCHAR(255) NOT NULL,
+  confirmed_at TIMESTAMP NULL,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  failed_attempts INT NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE webauthn_credentials (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id BIGINT NOT NULL,
+  credential_id VARBINARY(1023) NOT NULL,
+  public_key BLOB NOT NULL,
+  attestation_type VARCHAR(32) NOT NULL DEFAULT '',
+  aaguid VARBINARY(16) NULL,
+  sign_count INT UNSIGNED NOT NULL DEFAULT 0,
+  transports VARCHAR(255) NOT NULL DEFAULT '',
+  backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+  backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+  label VARCHAR(100) NOT NULL DEFAULT '',
+  last_used_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_webauthn_credentials_credential_id (credential_id),
+  INDEX idx_webauthn_credentials_user_id (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS webauthn_credentials;
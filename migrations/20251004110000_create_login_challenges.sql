@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE login_challenges (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id BIGINT NOT NULL,
+  kind VARCHAR(20) NOT NULL,
+  secret_hash VARCHAR(255) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_login_challenges_secret_hash (secret_hash),
+  INDEX idx_login_challenges_user_id_kind (user_id, kind),
+  FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_challenges;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE AFTER email;
+
+-- accounts created before self-registration were set up by an admin
+UPDATE users SET email_verified = TRUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN email_verified;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Existing clients start without audiences and can no longer introspect
+-- until granted some with `clients audiences`.
+ALTER TABLE oauth_clients ADD COLUMN audiences VARCHAR(500) NOT NULL DEFAULT '' AFTER name;
+
+-- +goose Down
+ALTER TABLE oauth_clients DROP COLUMN audiences;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN password_failed_attempts INT NOT NULL DEFAULT 0 AFTER password,
+    ADD COLUMN password_last_failed_at TIMESTAMP NULL AFTER password_failed_attempts;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN password_last_failed_at,
+    DROP COLUMN password_failed_attempts;
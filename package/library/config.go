@@ -3,6 +3,8 @@ package library
 import (
 	"fmt"
 	"log"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
@@ -15,6 +17,8 @@ type Env struct {
 	FileServerPort string `yaml:"fileServerPort"`
 	Timezone       string `yaml:"timezone"`
 
+	TrustedProxies []string `yaml:"trustedProxies"`
+
 	Database struct {
 		Host            string `yaml:"host"`
 		Database        string `yaml:"database"`
@@ -28,9 +32,103 @@ type Env struct {
 
 	Paseto struct {
 		Key                string `yaml:"key"`
+		KeyringFile        string `yaml:"keyringFile"`
+		Purpose            string `yaml:"purpose"`
+		Version            string `yaml:"version"`
+		PrivateKey         string `yaml:"privateKey"`
+		KeyId              string `yaml:"keyId"`
 		AccessTokenExpiry  string `yaml:"accessTokenExpiry"`
 		RefreshTokenExpiry string `yaml:"refreshTokenExpiry"`
+
+		ImpersonationTokenExpiry string `yaml:"impersonationTokenExpiry"`
 	} `yaml:"paseto"`
+
+	Token struct {
+		Format            string   `yaml:"format"`
+		Issuer            string   `yaml:"issuer"`
+		Audience          string   `yaml:"audience"`
+		ExchangeAudiences []string `yaml:"exchangeAudiences"`
+	} `yaml:"token"`
+
+	SessionCache struct {
+		Driver string `yaml:"driver"`
+		Size   int    `yaml:"size"`
+		Ttl    string `yaml:"ttl"`
+		Redis  struct {
+			Address  string `yaml:"address"`
+			Password string `yaml:"password"`
+			Db       int    `yaml:"db"`
+		} `yaml:"redis"`
+	} `yaml:"sessionCache"`
+
+	Cookie struct {
+		Enabled  bool   `yaml:"enabled"`
+		SameSite string `yaml:"sameSite"`
+	} `yaml:"cookie"`
+
+	TwoFactor struct {
+		Issuer             string `yaml:"issuer"`
+		EncryptionKey      string `yaml:"encryptionKey"`
+		PendingTokenExpiry string `yaml:"pendingTokenExpiry"`
+	} `yaml:"twoFactor"`
+
+	WebAuthn struct {
+		RPID          string   `yaml:"rpId"`
+		RPDisplayName string   `yaml:"rpDisplayName"`
+		RPOrigins     []string `yaml:"rpOrigins"`
+	} `yaml:"webAuthn"`
+
+	Passwordless struct {
+		MagicLinkURL    string `yaml:"magicLinkUrl"`
+		MagicLinkExpiry string `yaml:"magicLinkExpiry"`
+		EmailOTPExpiry  string `yaml:"emailOTPExpiry"`
+	} `yaml:"passwordless"`
+
+	Registration struct {
+		DefaultRole        string `yaml:"defaultRole"`
+		VerifyURL          string `yaml:"verifyUrl"`
+		VerificationExpiry string `yaml:"verificationExpiry"`
+		UnverifiedLogin    string `yaml:"unverifiedLogin"`
+	} `yaml:"registration"`
+
+	PasswordPolicy struct {
+		MinLength        int    `yaml:"minLength"`
+		RequireUpper     bool   `yaml:"requireUpper"`
+		RequireLower     bool   `yaml:"requireLower"`
+		RequireDigit     bool   `yaml:"requireDigit"`
+		RequireSymbol    bool   `yaml:"requireSymbol"`
+		MaxRepeats       int    `yaml:"maxRepeats"`
+		DisallowUserInfo bool   `yaml:"disallowUserInfo"`
+		BreachedListFile string `yaml:"breachedListFile"`
+	} `yaml:"passwordPolicy"`
+
+	PasswordReset struct {
+		URL    string `yaml:"url"`
+		Expiry string `yaml:"expiry"`
+	} `yaml:"passwordReset"`
+
+	Mailer struct {
+		Driver    string `yaml:"driver"`
+		From      string `yaml:"from"`
+		OutboxDir string `yaml:"outboxDir"`
+		Smtp      struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port"`
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+		} `yaml:"smtp"`
+	} `yaml:"mailer"`
+
+	Session struct {
+		IdleTimeout      string `yaml:"idleTimeout"`
+		AbsoluteLifetime string `yaml:"absoluteLifetime"`
+	} `yaml:"session"`
+
+	Jwt struct {
+		Algorithm      string `yaml:"algorithm"`
+		PrivateKeyFile string `yaml:"privateKeyFile"`
+		KeyId          string `yaml:"keyId"`
+	} `yaml:"jwt"`
 }
 
 var (
@@ -100,3 +198,81 @@ func RefreshTokenExpiry() time.Duration {
 	expiry := viper.GetString("paseto.refreshTokenExpiry")
 	return ParseTimeDuration(expiry, 7*24*time.Hour) // Default 7 days
 }
+
+func ImpersonationTokenExpiry() time.Duration {
+	expiry := viper.GetString("paseto.impersonationTokenExpiry")
+	return ParseTimeDuration(expiry, constants.DefaultImpersonationTokenExpiry)
+}
+
+func MFAPendingTokenExpiry() time.Duration {
+	expiry := viper.GetString("twoFactor.pendingTokenExpiry")
+	return ParseTimeDuration(expiry, constants.DefaultMFAPendingTokenExpiry)
+}
+
+func MagicLinkExpiry() time.Duration {
+	expiry := viper.GetString("passwordless.magicLinkExpiry")
+	return ParseTimeDuration(expiry, constants.DefaultMagicLinkExpiry)
+}
+
+func EmailOTPExpiry() time.Duration {
+	expiry := viper.GetString("passwordless.emailOTPExpiry")
+	return ParseTimeDuration(expiry, constants.DefaultEmailOTPExpiry)
+}
+
+func EmailVerificationExpiry() time.Duration {
+	expiry := viper.GetString("registration.verificationExpiry")
+	return ParseTimeDuration(expiry, constants.DefaultEmailVerificationExpiry)
+}
+
+func PasswordResetExpiry() time.Duration {
+	expiry := viper.GetString("passwordReset.expiry")
+	return ParseTimeDuration(expiry, constants.DefaultPasswordResetExpiry)
+}
+
+func SessionIdleTimeout() time.Duration {
+	timeout := viper.GetString("session.idleTimeout")
+	return ParseTimeDuration(timeout, constants.DefaultSessionIdleTimeout)
+}
+
+func SessionAbsoluteLifetime() time.Duration {
+	lifetime := viper.GetString("session.absoluteLifetime")
+	return ParseTimeDuration(lifetime, constants.DefaultSessionAbsoluteLifetime)
+}
+
+func SessionCacheTTL() time.Duration {
+	ttl := viper.GetString("sessionCache.ttl")
+	return ParseTimeDuration(ttl, constants.DefaultSessionCacheTTL)
+}
+
+func SessionCacheSize() int {
+	if !viper.IsSet("sessionCache.size") {
+		return constants.DefaultSessionCacheSize
+	}
+	return viper.GetInt("sessionCache.size")
+}
+
+// TrustedProxies parses trustedProxies, CIDR ranges or single addresses of
+// the reverse proxies whose forwarding headers are believed.
+func TrustedProxies(env Env) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range env.TrustedProxies {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
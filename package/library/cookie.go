@@ -0,0 +1,92 @@
+package library
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/petershaan12/go-auth-clean-arch/resource/constants"
+)
+
+func cookieSameSite(env Env) http.SameSite {
+	switch strings.ToLower(env.Cookie.SameSite) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+func setCookie(c echo.Context, env Env, name string, value string, maxAge time.Duration, httpOnly bool) {
+	c.SetCookie(&http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: cookieSameSite(env),
+	})
+}
+
+// SetTokenCookies hands the token pair to a browser in HttpOnly cookies,
+// together with a fresh CSRF token the page can read and echo back in
+// the X-CSRF-Token header.
+func SetTokenCookies(c echo.Context, env Env, accessToken string, accessExpiry time.Duration, refreshToken string, refreshExpiry time.Duration) error {
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		return err
+	}
+
+	setCookie(c, env, constants.AccessTokenCookie, accessToken, accessExpiry, true)
+	setCookie(c, env, constants.RefreshTokenCookie, refreshToken, refreshExpiry, true)
+	setCookie(c, env, constants.CSRFTokenCookie, base64.RawURLEncoding.EncodeToString(csrf), refreshExpiry, false)
+	return nil
+}
+
+// ClearTokenCookies removes the cookies set by SetTokenCookies.
+func ClearTokenCookies(c echo.Context, env Env) {
+	for _, name := range []string{constants.AccessTokenCookie, constants.RefreshTokenCookie, constants.CSRFTokenCookie} {
+		c.SetCookie(&http.Cookie{
+			Name:     name,
+			Path:     "/",
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: name != constants.CSRFTokenCookie,
+			SameSite: cookieSameSite(env),
+		})
+	}
+}
+
+// CSRFProtection is double-submit CSRF protection for cookie mode. A
+// state-changing request that carries the access or refresh cookie must
+// repeat the CSRF cookie in the X-CSRF-Token header, which another site
+// can not read. Requests without token cookies, such as bearer clients
+// and the first login, are not affected.
+func CSRFProtection(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(c)
+		}
+
+		_, accessErr := c.Cookie(constants.AccessTokenCookie)
+		_, refreshErr := c.Cookie(constants.RefreshTokenCookie)
+		if accessErr != nil && refreshErr != nil {
+			return next(c)
+		}
+
+		cookie, err := c.Cookie(constants.CSRFTokenCookie)
+		header := c.Request().Header.Get(constants.CSRFTokenHeader)
+		if err != nil || header == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			return echo.NewHTTPError(http.StatusForbidden, "invalid CSRF token")
+		}
+		return next(c)
+	}
+}
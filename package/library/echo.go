@@ -30,7 +30,7 @@ func dateValidation(fl validator.FieldLevel) bool {
 }
 
 // Update fungsi NewRequestHandler dengan fitur tambahan
-func NewRequestHandler() *RequestHandler {
+func NewRequestHandler(env Env) *RequestHandler {
 	engine := echo.New()
 	engineFile := echo.New()
 
@@ -55,10 +55,18 @@ func NewRequestHandler() *RequestHandler {
 			"X-Requested-With",
 			"Accept",
 			"Origin",
+			"DPoP",
+			constants.CSRFTokenHeader,
 		},
 		AllowCredentials: true,
 	}))
 
+	// cookie mode lets browsers authenticate with HttpOnly cookies, which
+	// they also send on cross-site requests, hence the CSRF check
+	if env.Cookie.Enabled {
+		engine.Use(CSRFProtection)
+	}
+
 	engine.Use(middleware.RequestID())
 	engine.Use(middleware.Logger())
 	engine.Use(middleware.Recover())
@@ -0,0 +1,11 @@
+package constants
+
+// Cookie mode keeps tokens out of JavaScript's reach. The __Host- prefix
+// makes browsers require Secure, Path=/ and no Domain, so a subdomain can
+// not plant or overwrite them.
+const (
+	AccessTokenCookie  string = "__Host-access_token"
+	RefreshTokenCookie string = "__Host-refresh_token"
+	CSRFTokenCookie    string = "__Host-csrf_token"
+	CSRFTokenHeader    string = "X-CSRF-Token"
+)
@@ -11,6 +11,11 @@ const (
 	DefaultDBPingInterval       time.Duration = 1 * time.Second
 	DefaultDBRetryAttempts      int           = 3
 
+	DefaultKeyringReloadInterval   time.Duration = 1 * time.Minute
+	DefaultRevocationPurgeInterval time.Duration = 10 * time.Minute
+	DefaultSessionCacheTTL         time.Duration = 30 * time.Second
+	DefaultSessionCacheSize        int           = 10000
+
 	DefaultWorkerNamespace     string = "default"
 	DefaultWorkerConcurrency   int    = 10
 	DefaultWorkerRetryAttempts int    = 3
@@ -24,4 +29,64 @@ const (
 	DBTransaction       string = "db_trx"
 	DbContext           string = "ctx_db"
 	Unauthorized        string = "Unauthorized"
+	Forbidden           string = "Forbidden"
+)
+
+const (
+	DefaultImpersonationTokenExpiry time.Duration = 10 * time.Minute
+	DefaultDPoPProofWindow          time.Duration = time.Minute
+	DefaultSessionIdleTimeout       time.Duration = 24 * time.Hour
+	DefaultSessionAbsoluteLifetime  time.Duration = 30 * 24 * time.Hour
+)
+
+const (
+	DefaultTwoFactorIssuer       string        = "go-auth-clean-arch"
+	DefaultMFAPendingTokenExpiry time.Duration = 5 * time.Minute
+	DefaultTwoFactorMaxAttempts  int           = 5
+	DefaultTwoFactorLockout      time.Duration = 15 * time.Minute
+	DefaultRecoveryCodeCount     int           = 10
+)
+
+const (
+	DefaultWebAuthnDisplayName     string        = "go-auth-clean-arch"
+	DefaultWebAuthnCeremonyTimeout time.Duration = 5 * time.Minute
+)
+
+const (
+	DefaultMagicLinkURL         string        = "http://localhost:3000/login/magic-link"
+	DefaultMagicLinkExpiry      time.Duration = 15 * time.Minute
+	DefaultEmailOTPExpiry       time.Duration = 10 * time.Minute
+	DefaultEmailOTPMaxAttempts  int           = 5
+	DefaultLoginChallengeLimit  int           = 5
+	DefaultLoginChallengeWindow time.Duration = 15 * time.Minute
+)
+
+const (
+	DefaultMailFrom string = "go-auth-clean-arch <no-reply@localhost>"
+)
+
+const (
+	DefaultRegistrationRole        string        = RoleUser
+	DefaultEmailVerifyURL          string        = "http://localhost:3000/verify-email"
+	DefaultEmailVerificationExpiry time.Duration = 24 * time.Hour
+	DefaultMailOutboxDir           string        = "outbox"
+)
+
+const (
+	DefaultPasswordResetURL    string        = "http://localhost:3000/reset-password"
+	DefaultPasswordResetExpiry time.Duration = 1 * time.Hour
+	DefaultPasswordMaxAttempts int           = 5
+	DefaultPasswordLockout     time.Duration = 15 * time.Minute
+)
+
+const (
+	DefaultPasswordMinLength int = 12
+	PasswordMaxBytes         int = 72 // bcrypt ignores or rejects anything longer
+)
+
+// Values of registration.unverifiedLogin.
+const (
+	UnverifiedLoginBlock   string = "block"
+	UnverifiedLoginLimited string = "limited"
+	UnverifiedLoginAllow   string = "allow"
 )
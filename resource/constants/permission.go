@@ -0,0 +1,19 @@
+package constants
+
+// Role names as stored in the roles table.
+const (
+	RoleSuperAdmin string = "superadmin"
+	RoleUser       string = "user"
+)
+
+// Scopes are permission names from the permissions table; tokens carry the
+// scopes granted to the user's role.
+const (
+	ScopeUsersRead        string = "users:read"
+	ScopeUsersWrite       string = "users:write"
+	ScopeUsersDelete      string = "users:delete"
+	ScopeUsersImpersonate string = "users:impersonate"
+
+	// ScopeProfileRead lets a user read their own record only.
+	ScopeProfileRead string = "profile:read"
+)
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AuditLogTable = "audit_logs"
+
+	AuditImpersonationStarted = "impersonation.started"
+	AuditImpersonatedRequest  = "impersonation.request"
+)
+
+type (
+	// AuditLog records who did what to whom. ActorId is the user acting,
+	// SubjectId the user acted upon; they differ during impersonation.
+	AuditLog struct {
+		Id        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+		Action    string    `json:"action" gorm:"type:varchar(100)"`
+		ActorId   *int64    `json:"actor_id,omitempty" gorm:"column:actor_id"`
+		SubjectId *int64    `json:"subject_id,omitempty" gorm:"column:subject_id"`
+		IPAddress string    `json:"ip_address" gorm:"column:ip_address;type:varchar(45)"`
+		UserAgent string    `json:"user_agent" gorm:"column:user_agent;type:varchar(255)"`
+		Detail    string    `json:"detail" gorm:"type:varchar(255)"`
+		CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	}
+
+	AuditMethodRepository interface {
+		WithContext(ctx context.Context) AuditMethodRepository
+		Create(data *AuditLog) (result *AuditLog, err error)
+	}
+)
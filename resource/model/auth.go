@@ -2,24 +2,32 @@ package model
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
-	"regexp"
-	"strings"
 
 	"github.com/petershaan12/go-auth-clean-arch/internal/token"
 )
 
+var (
+	ErrImpersonationDenied = errors.New("not allowed while impersonating another user")
+	ErrImpersonateSelf     = errors.New("cannot impersonate yourself")
+)
+
 type (
 	AuthReq struct {
-		Email     string `json:"email" validate:"required,email"`
-		Password  string `json:"password" validate:"required,min=6,max=128"`
-		IPAddress string `json:"ip_address,omitempty"`
+		Email       string `json:"email" validate:"required,email"`
+		Password    string `json:"password" validate:"required,min=6,max=128"`
+		IPAddress   string `json:"ip_address,omitempty"`
+		DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100"`
 	}
 
+	// RefreshTokenReq carries the refresh token and, optionally, the access
+	// token issued with it, which may already have expired.
 	RefreshTokenReq struct {
 		AccessToken  string `json:"at" query:"at"`
 		RefreshToken string `json:"rt" query:"rt"`
+
+		// DPoPThumbprint is the key of the request's verified DPoP proof.
+		DPoPThumbprint string `json:"-"`
 	}
 
 	TokenOutput struct {
@@ -27,55 +35,28 @@ type (
 		AccessToken      string `json:"a"`
 		RefreshToken     string `json:"r"`
 		ExpiredToken     string `json:"e"`
+		MFAToken         string `json:"mfa_token,omitempty"`
 		UserId           int    `json:"user_id"`
 		RequireTwoFactor bool   `json:"require_two_factor"`
 		Enable2FA        bool   `json:"enable_2fa"`
 	}
 
 	AuthMethodService interface {
-		Login(ctx context.Context, req *AuthReq) (result *User, err error)
-		GenerateToken(ctx context.Context, user *User) (result *TokenOutput, err error)
+		Login(ctx context.Context, req *AuthReq, device *DeviceInfo) (result *TokenOutput, err error)
+		GenerateToken(ctx context.Context, user *User, device *DeviceInfo) (result *TokenOutput, err error)
 		VerifyRefreshToken(ctx context.Context, req *RefreshTokenReq) (result *TokenOutput, err error)
 		Logout(ctx context.Context, payload *token.Payload) error
+		LogoutAll(ctx context.Context, payload *token.Payload) error
+		Impersonate(ctx context.Context, actor *token.Payload, userId int64, device *DeviceInfo) (result *TokenOutput, err error)
+		VerifyTwoFactor(ctx context.Context, req *TwoFactorVerifyReq, device *DeviceInfo) (result *TokenOutput, err error)
+		SendMagicLink(ctx context.Context, email string) error
+		VerifyMagicLink(ctx context.Context, req *MagicLinkVerifyReq, device *DeviceInfo) (result *TokenOutput, err error)
+		SendEmailOTP(ctx context.Context, email string) error
+		VerifyEmailOTP(ctx context.Context, req *EmailOTPVerifyReq, device *DeviceInfo) (result *TokenOutput, err error)
+		SendEmailVerification(ctx context.Context, email string) error
+		VerifyEmail(ctx context.Context, req *VerifyEmailReq) error
+		SendPasswordReset(ctx context.Context, email string) error
+		ResetPassword(ctx context.Context, req *ResetPasswordReq) error
+		ChangePassword(ctx context.Context, payload *token.Payload, req *ChangePasswordReq, device *DeviceInfo) (result *TokenOutput, err error)
 	}
 )
-
-func ValidatePassword(password string) error {
-	// Decode base64 input
-	passBytes, err := base64.StdEncoding.DecodeString(password)
-	if err != nil {
-		return err
-	}
-	pass := string(passBytes)
-
-	if len(pass) < 12 {
-		return errors.New("password must be at least 12 characters long")
-	}
-
-	// simple regex checks for each required class
-	hasNumber, _ := regexp.MatchString(`[0-9]`, pass)
-	hasUpper, _ := regexp.MatchString(`[A-Z]`, pass)
-	hasLower, _ := regexp.MatchString(`[a-z]`, pass)
-	hasSpecial, _ := regexp.MatchString(`[!@#\$%\^&\*\(\)\-_=+\[\]\{\};:'",.<>/?]`, pass)
-
-	if hasNumber && hasUpper && hasLower && hasSpecial {
-		return nil
-	}
-
-	var b strings.Builder
-	b.WriteString("Password must contain ")
-	if !hasNumber {
-		b.WriteString("at least one number, ")
-	}
-	if !hasUpper {
-		b.WriteString("at least one uppercase letter, ")
-	}
-	if !hasLower {
-		b.WriteString("at least one lowercase letter, ")
-	}
-	if !hasSpecial {
-		b.WriteString("at least one special character, ")
-	}
-
-	return errors.New(strings.TrimSuffix(b.String(), ", ") + ".")
-}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// SessionCache keeps users' session versions so token checks can skip the
+// database. Set never lowers a cached version, so a reader that loaded the
+// version just before an increment can not overwrite the newer one.
+// Implementations live in internal/cache.
+type SessionCache interface {
+	Get(ctx context.Context, userId int64) (version int, ok bool)
+	Set(ctx context.Context, userId int64, version int)
+	Delete(ctx context.Context, userId int64)
+}
+
+// ChallengeStore keeps state between the two requests of a challenge
+// ceremony, such as WebAuthn registration and login. Take returns a value
+// at most once.
+type ChallengeStore interface {
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Take(ctx context.Context, key string) (value []byte, ok bool)
+}
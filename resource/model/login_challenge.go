@@ -0,0 +1,67 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	LoginChallengeTable = "login_challenges"
+)
+
+const (
+	LoginChallengeMagicLink         = "magic_link"
+	LoginChallengeEmailOTP          = "email_otp"
+	LoginChallengeEmailVerification = "email_verification"
+	LoginChallengePasswordReset     = "password_reset"
+)
+
+var (
+	ErrInvalidLoginChallenge = errors.New("invalid or expired sign-in link or code")
+	ErrInvalidVerification   = errors.New("invalid or expired verification link")
+	ErrInvalidPasswordReset  = errors.New("invalid or expired password reset link")
+)
+
+type (
+	// LoginChallenge is an emailed single-use secret: a magic link, a
+	// one-time code, an email verification or a password reset link. Only a hash of the
+	// secret is stored: SHA-256 for high-entropy link tokens so they can be
+	// looked up, bcrypt for six digit codes.
+	LoginChallenge struct {
+		Id         int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+		UserId     int64      `json:"user_id" gorm:"column:user_id"`
+		Kind       string     `json:"kind" gorm:"column:kind;type:varchar(20)"`
+		SecretHash string     `json:"-" gorm:"column:secret_hash;type:varchar(255)"`
+		Attempts   int        `json:"-" gorm:"column:attempts"`
+		ExpiresAt  time.Time  `json:"expires_at" gorm:"column:expires_at"`
+		UsedAt     *time.Time `json:"used_at,omitempty" gorm:"column:used_at"`
+		CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	}
+
+	PasswordlessReq struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	MagicLinkVerifyReq struct {
+		Token       string `json:"token" validate:"required"`
+		DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100"`
+	}
+
+	EmailOTPVerifyReq struct {
+		Email       string `json:"email" validate:"required,email"`
+		Code        string `json:"code" validate:"required,len=6,numeric"`
+		DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100"`
+	}
+
+	LoginChallengeMethodRepository interface {
+		WithContext(ctx context.Context) LoginChallengeMethodRepository
+		Create(data *LoginChallenge) (result *LoginChallenge, err error)
+		FindActiveByHash(kind string, secretHash string) (result *LoginChallenge, err error)
+		FindLatestActive(userId int64, kind string) (result *LoginChallenge, err error)
+		CountSince(userId int64, kind string, since time.Time) (total int64, err error)
+		Use(id int64) (used bool, err error)
+		UseAllByUser(userId int64, kind string) error
+		ClaimAttempt(id int64, maxAttempts int) (claimed bool, err error)
+	}
+)
@@ -0,0 +1,15 @@
+package model
+
+import "context"
+
+// MailMessage is a plain text email.
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email. Implementations live in internal/mailer.
+type Mailer interface {
+	Send(ctx context.Context, msg *MailMessage) error
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+)
+
+const (
+	OAuthClientTable = "oauth_clients"
+
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+type (
+	// OAuthClient is a resource server allowed to call the /oauth endpoints.
+	// ClientSecret holds a bcrypt hash, never the secret itself. Audiences
+	// is a space separated list of the token audiences the client may
+	// introspect and revoke.
+	OAuthClient struct {
+		Id           int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+		ClientId     string     `json:"client_id" gorm:"column:client_id;type:varchar(100)"`
+		ClientSecret string     `json:"-" gorm:"column:client_secret;type:varchar(255)"`
+		Name         string     `json:"name" gorm:"type:varchar(100)"`
+		Audiences    string     `json:"audiences" gorm:"column:audiences;type:varchar(500)"`
+		CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt    *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	}
+
+	IntrospectionReq struct {
+		Token         string `form:"token" validate:"required"`
+		TokenTypeHint string `form:"token_type_hint"`
+	}
+
+	RevocationReq struct {
+		Token         string `form:"token" validate:"required"`
+		TokenTypeHint string `form:"token_type_hint"`
+	}
+
+	// IntrospectionResponse is the RFC 7662 response body. Only Active is
+	// sent for tokens that are invalid, expired or revoked.
+	IntrospectionResponse struct {
+		Active    bool                `json:"active"`
+		Sub       string              `json:"sub,omitempty"`
+		Exp       int64               `json:"exp,omitempty"`
+		Iat       int64               `json:"iat,omitempty"`
+		Nbf       int64               `json:"nbf,omitempty"`
+		Iss       string              `json:"iss,omitempty"`
+		Aud       string              `json:"aud,omitempty"`
+		Jti       string              `json:"jti,omitempty"`
+		TokenType string              `json:"token_type,omitempty"`
+		Scope     string              `json:"scope,omitempty"`
+		Cnf       *token.Confirmation `json:"cnf,omitempty"`
+	}
+
+	// TokenExchangeReq is an RFC 8693 token exchange request. Audience names
+	// the service the new token is for and Scope, when set, must be a subset
+	// of the subject token's scopes.
+	TokenExchangeReq struct {
+		GrantType          string `form:"grant_type" validate:"required"`
+		SubjectToken       string `form:"subject_token" validate:"required"`
+		SubjectTokenType   string `form:"subject_token_type" validate:"required"`
+		RequestedTokenType string `form:"requested_token_type"`
+		Audience           string `form:"audience"`
+		Scope              string `form:"scope"`
+	}
+
+	TokenExchangeResponse struct {
+		AccessToken     string `json:"access_token"`
+		IssuedTokenType string `json:"issued_token_type"`
+		TokenType       string `json:"token_type"`
+		ExpiresIn       int64  `json:"expires_in"`
+		Scope           string `json:"scope,omitempty"`
+	}
+
+	// OAuthError is the RFC 6749 section 5.2 error body returned by the
+	// token endpoint.
+	OAuthError struct {
+		Code        string `json:"error"`
+		Description string `json:"error_description,omitempty"`
+	}
+
+	OAuthClientMethodRepository interface {
+		WithContext(ctx context.Context) OAuthClientMethodRepository
+		FindByClientId(clientId string) (result *OAuthClient, err error)
+		Create(data *OAuthClient) (result *OAuthClient, err error)
+		SetAudiences(clientId string, audiences string) error
+	}
+
+	OAuthMethodService interface {
+		AuthenticateClient(ctx context.Context, clientId string, clientSecret string) (result *OAuthClient, err error)
+		Introspect(ctx context.Context, client *OAuthClient, req *IntrospectionReq) (result *IntrospectionResponse, err error)
+		Revoke(ctx context.Context, client *OAuthClient, req *RevocationReq) error
+		Exchange(ctx context.Context, client *OAuthClient, req *TokenExchangeReq) (result *TokenExchangeResponse, err error)
+	}
+)
+
+// HandlesAudience reports whether tokens for audience are the client's to
+// introspect or revoke.
+func (c *OAuthClient) HandlesAudience(audience string) bool {
+	return audience != "" && slices.Contains(strings.Fields(c.Audiences), audience)
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
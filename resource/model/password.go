@@ -0,0 +1,62 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidCurrentPassword  = errors.New("current password is incorrect")
+	ErrTooManyPasswordAttempts = errors.New("too many incorrect passwords, try again later")
+)
+
+// Password policy rules, as reported in PasswordViolation.Rule.
+const (
+	PasswordRuleMinLength  = "min_length"
+	PasswordRuleMaxLength  = "max_length"
+	PasswordRuleUpper      = "uppercase"
+	PasswordRuleLower      = "lowercase"
+	PasswordRuleDigit      = "digit"
+	PasswordRuleSymbol     = "symbol"
+	PasswordRuleMaxRepeats = "max_repeats"
+	PasswordRuleUserInfo   = "user_info"
+	PasswordRuleBreached   = "breached"
+)
+
+type (
+	ResetPasswordReq struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	ChangePasswordReq struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required,nefield=CurrentPassword"`
+	}
+
+	PasswordViolation struct {
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+
+	// PasswordPolicyError lists every rule a password breaks, so a client
+	// can show them all at once. It is sent as the error_message of a 400.
+	PasswordPolicyError struct {
+		Message    string              `json:"message"`
+		Violations []PasswordViolation `json:"violations"`
+	}
+
+	PasswordPolicy interface {
+		// Validate returns a *PasswordPolicyError when password breaks the
+		// policy. username and email belong to the account it is for.
+		Validate(password string, username string, email string) error
+	}
+)
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return e.Message + ": " + strings.Join(messages, "; ")
+}
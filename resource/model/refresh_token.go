@@ -0,0 +1,40 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	RefreshTokenTable = "refresh_tokens"
+)
+
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrRefreshTokenRevoked = errors.New("refresh token family has been revoked")
+)
+
+type (
+	// RefreshToken is one link in a refresh token family. Every refresh
+	// consumes the presented token and stores its successor under the same
+	// FamilyId, so presenting a consumed token again means it was copied.
+	RefreshToken struct {
+		Jti       string     `json:"jti" gorm:"column:jti;primaryKey"`
+		FamilyId  string     `json:"family_id" gorm:"column:family_id;type:varchar(64)"`
+		ParentJti *string    `json:"parent_jti,omitempty" gorm:"column:parent_jti;type:varchar(64)"`
+		UserId    int64      `json:"user_id" gorm:"column:user_id"`
+		Consumed  bool       `json:"consumed" gorm:"column:consumed;default:false"`
+		ExpiresAt time.Time  `json:"expires_at" gorm:"column:expires_at"`
+		RevokedAt *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+		CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	}
+
+	RefreshTokenMethodRepository interface {
+		WithContext(ctx context.Context) RefreshTokenMethodRepository
+		Create(data *RefreshToken) (result *RefreshToken, err error)
+		FindByJti(jti string) (result *RefreshToken, err error)
+		Consume(jti string) (consumed bool, err error)
+		RevokeFamily(familyId string) error
+	}
+)
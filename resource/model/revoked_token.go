@@ -0,0 +1,29 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	RevokedTokenTable = "revoked_tokens"
+)
+
+type (
+	// RevokedToken blocks a single token by jti. Rows are only needed until
+	// the token would have expired anyway and are purged after ExpiresAt.
+	RevokedToken struct {
+		Jti       string    `json:"jti" gorm:"column:jti;primaryKey"`
+		UserId    int64     `json:"user_id" gorm:"column:user_id"`
+		ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at"`
+		RevokedAt time.Time `json:"revoked_at" gorm:"column:revoked_at;autoCreateTime"`
+	}
+
+	RevokedTokenMethodRepository interface {
+		WithContext(ctx context.Context) RevokedTokenMethodRepository
+		Create(data *RevokedToken) (result *RevokedToken, err error)
+		Claim(data *RevokedToken) (claimed bool, err error)
+		DeleteExpired(now time.Time) (deleted int64, err error)
+		IsRevoked(ctx context.Context, jti string) (bool, error)
+	}
+)
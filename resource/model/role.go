@@ -1,6 +1,28 @@
 package model
 
-type Role struct {
-	ID   uint   `gorm:"primaryKey"`
-	Name string `gorm:"type:varchar(50);unique"`
-}
+import "context"
+
+const (
+	RoleTable           = "roles"
+	PermissionTable     = "permissions"
+	RolePermissionTable = "role_permissions"
+)
+
+type (
+	Role struct {
+		ID   uint   `gorm:"primaryKey"`
+		Name string `gorm:"type:varchar(50);unique"`
+	}
+
+	Permission struct {
+		ID   uint   `gorm:"primaryKey"`
+		Name string `gorm:"type:varchar(100);unique"`
+	}
+
+	RoleMethodRepository interface {
+		WithContext(ctx context.Context) RoleMethodRepository
+		FindByID(id int64) (result *Role, err error)
+		FindByName(name string) (result *Role, err error)
+		GetPermissions(roleId int64) (result []string, err error)
+	}
+)
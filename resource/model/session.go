@@ -0,0 +1,48 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/petershaan12/go-auth-clean-arch/internal/token"
+)
+
+const (
+	SessionTable = "sessions"
+)
+
+type (
+	// Session is one signed-in device. Tokens reference it through their
+	// sid claim, so revoking a session only logs out that device.
+	Session struct {
+		Id          string     `json:"id" gorm:"column:id;primaryKey"`
+		UserId      int64      `json:"user_id" gorm:"column:user_id"`
+		DeviceLabel string     `json:"device_label" gorm:"column:device_label;type:varchar(100)"`
+		IPAddress   string     `json:"ip_address" gorm:"column:ip_address;type:varchar(45)"`
+		UserAgent   string     `json:"user_agent" gorm:"column:user_agent;type:varchar(255)"`
+		CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+		LastSeenAt  time.Time  `json:"last_seen_at" gorm:"column:last_seen_at"`
+		RevokedAt   *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	}
+
+	// DeviceInfo describes the client a new session is opened for.
+	// DPoPThumbprint is set when the client proved possession of a DPoP
+	// key; the session's tokens are then bound to it.
+	DeviceInfo struct {
+		Label          string
+		IPAddress      string
+		UserAgent      string
+		DPoPThumbprint string
+	}
+
+	SessionMethodRepository interface {
+		WithContext(ctx context.Context) SessionMethodRepository
+		Create(data *Session) (result *Session, err error)
+		Touch(sessionId string) error
+		Revoke(sessionId string) error
+		RevokeAllByUser(userId int64) error
+		RevokeOthersByUser(userId int64, keepSessionId string) error
+		GetSessionActivity(ctx context.Context, sessionId string) (*token.SessionActivity, error)
+		MarkSessionSeen(ctx context.Context, sessionId string) error
+	}
+)
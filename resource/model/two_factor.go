@@ -0,0 +1,98 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	UserTOTPTable     = "user_totp"
+	RecoveryCodeTable = "recovery_codes"
+)
+
+var (
+	ErrTwoFactorNotEnrolled     = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrInvalidTwoFactorCode     = errors.New("invalid two-factor code")
+	ErrTooManyTwoFactorAttempts = errors.New("too many invalid two-factor codes, try again later")
+	ErrTwoFactorNotConfigured   = errors.New("two-factor encryption key is not configured")
+	ErrMFATokenUsed             = errors.New("mfa token has already been used")
+)
+
+type (
+	// UserTOTP is a user's TOTP authenticator. Secret is encrypted with
+	// twoFactor.encryptionKey; LastUsedStep stops a code from being
+	// accepted twice.
+	UserTOTP struct {
+		UserId         int64      `json:"user_id" gorm:"column:user_id;primaryKey"`
+		Secret         string     `json:"-" gorm:"column:secret;type:varchar(255)"`
+		ConfirmedAt    *time.Time `json:"confirmed_at,omitempty" gorm:"column:confirmed_at"`
+		LastUsedStep   int64      `json:"-" gorm:"column:last_used_step"`
+		FailedAttempts int        `json:"-" gorm:"column:failed_attempts"`
+		LastFailedAt   *time.Time `json:"-" gorm:"column:last_failed_at"`
+		CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	}
+
+	// RecoveryCode is a single-use code that stands in for a TOTP code.
+	// Only its bcrypt hash is stored.
+	RecoveryCode struct {
+		Id        int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+		UserId    int64      `json:"user_id" gorm:"column:user_id"`
+		CodeHash  string     `json:"-" gorm:"column:code_hash;type:varchar(255)"`
+		UsedAt    *time.Time `json:"used_at,omitempty" gorm:"column:used_at"`
+		CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	}
+
+	// TwoFactorEnrollment is shown once; neither the secret nor the
+	// recovery codes can be read back later.
+	TwoFactorEnrollment struct {
+		Secret        string   `json:"secret"`
+		OTPAuthURI    string   `json:"otpauth_uri"`
+		QRCode        string   `json:"qr_code"` // PNG data URI
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	RecoveryCodesOutput struct {
+		RecoveryCodes []string `json:"recovery_codes,omitempty"`
+		Remaining     int64    `json:"remaining"`
+	}
+
+	TwoFactorCodeReq struct {
+		Code string `json:"code" validate:"required"` // TOTP or recovery code
+	}
+
+	TwoFactorVerifyReq struct {
+		MFAToken    string `json:"mfa_token" validate:"required"`
+		Code        string `json:"code" validate:"required"` // TOTP or recovery code
+		DeviceLabel string `json:"device_label,omitempty" validate:"omitempty,max=100"`
+	}
+
+	UserTOTPMethodRepository interface {
+		WithContext(ctx context.Context) UserTOTPMethodRepository
+		FindByUserId(userId int64) (result *UserTOTP, err error)
+		Save(data *UserTOTP) (result *UserTOTP, err error)
+		Confirm(userId int64, step int64) error
+		UseStep(userId int64, step int64) (used bool, err error)
+		ClaimAttempt(userId int64, maxAttempts int, lockedSince time.Time) (claimed bool, err error)
+		ResetAttempts(userId int64) error
+	}
+
+	RecoveryCodeMethodRepository interface {
+		WithContext(ctx context.Context) RecoveryCodeMethodRepository
+		Replace(userId int64, codeHashes []string) error
+		FindUnused(userId int64) (result []*RecoveryCode, err error)
+		MarkUsed(id int64) (used bool, err error)
+		CountUnused(userId int64) (total int64, err error)
+	}
+
+	TwoFactorMethodService interface {
+		Enroll(ctx context.Context, userId int64, accountName string) (result *TwoFactorEnrollment, err error)
+		Confirm(ctx context.Context, userId int64, code string) error
+		IsEnabled(ctx context.Context, userId int64) (enabled bool, err error)
+		Check(ctx context.Context, userId int64, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userId int64, code string) (result *RecoveryCodesOutput, err error)
+		CountRecoveryCodes(ctx context.Context, userId int64) (result *RecoveryCodesOutput, err error)
+	}
+)
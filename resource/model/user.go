@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -12,6 +13,12 @@ type (
 	UserHasChangePassword string
 )
 
+var (
+	ErrEmailNotVerified = errors.New("email address has not been verified")
+	ErrEmailInUse       = errors.New("email already in use")
+	ErrUsernameInUse    = errors.New("username already in use")
+)
+
 const (
 	UserTable     = "users"
 	UserDeletedAt = "deleted_at"
@@ -22,6 +29,7 @@ type (
 		Id             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
 		Username       string     `json:"username" gorm:"type:varchar(100)"`
 		Email          string     `json:"email" gorm:"type:varchar(100)"`
+		EmailVerified  bool       `json:"email_verified" gorm:"column:email_verified"`
 		Fullname       string     `json:"fullname" gorm:"type:varchar(255)"`
 		RoleId         int64      `json:"role_id" gorm:"type:int(11)"`
 		SessionVersion int        `json:"session_version" gorm:"column:session_version;default:1"`
@@ -36,7 +44,20 @@ type (
 		Email    string `json:"email" validate:"required,email,max=100"`
 		Fullname string `json:"fullname" validate:"required,max=255"`
 		RoleID   int64  `json:"role_id" validate:"required"`
-		Password string `json:"password" validate:"required,min=6,max=128"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	// RegisterRequest is a self-service sign-up; the role comes from
+	// registration.defaultRole.
+	RegisterRequest struct {
+		Username string `json:"username" validate:"required,min=3,max=100"`
+		Email    string `json:"email" validate:"required,email,max=100"`
+		Fullname string `json:"fullname" validate:"required,max=255"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	VerifyEmailReq struct {
+		Token string `json:"token" validate:"required"`
 	}
 
 	UpdateUserRequest struct {
@@ -44,7 +65,7 @@ type (
 		Email    string `json:"email" validate:"omitempty,email,max=100"`
 		Fullname string `json:"fullname" validate:"omitempty,max=255"`
 		RoleID   int64  `json:"role_id" validate:"omitempty"`
-		Password string `json:"password" validate:"omitempty,min=6,max=128"`
+		Password string `json:"password" validate:"omitempty"`
 	}
 
 	UserMethodRepository interface {
@@ -55,6 +76,10 @@ type (
 		Create(req *User) (result *User, err error)
 		Update(data *User) (result *User, err error)
 		Delete(id int64) (err error)
+		MarkEmailVerified(id int64) error
+		UpdatePassword(id int64, passwordHash string) error
+		ClaimPasswordAttempt(id int64, maxAttempts int, lockedSince time.Time) (bool, error)
+		ResetPasswordAttempts(id int64) error
 		IncrementSessionVersion(ctx context.Context, userId int64) error
 		GetSessionVersion(ctx context.Context, userId int64) (int, error)
 	}
@@ -62,6 +87,7 @@ type (
 	UserMethodService interface {
 		List(ctx context.Context) (result []*User, err error)
 		Create(tx *gorm.DB, req *CreateUserRequest) (result *User, err error)
+		Register(tx *gorm.DB, req *RegisterRequest) (result *User, err error)
 		Update(c echo.Context, tx *gorm.DB, id int, req *UpdateUserRequest) (result *User, err error)
 		Delete(c echo.Context, tx *gorm.DB, id int) (err error)
 		GetByID(c echo.Context, id int) (result *User, err error)
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	WebAuthnCredentialTable = "webauthn_credentials"
+)
+
+var (
+	ErrWebAuthnNotConfigured     = errors.New("webauthn is not configured")
+	ErrWebAuthnCeremonyNotFound  = errors.New("webauthn ceremony not found or expired")
+	ErrWebAuthnVerification      = errors.New("webauthn verification failed")
+	ErrWebAuthnCredentialCloned  = errors.New("webauthn credential may have been cloned")
+	ErrWebAuthnCredentialUnknown = errors.New("webauthn credential is not registered")
+)
+
+type (
+	// WebAuthnCredential is a passkey or security key registered to a user.
+	// SignCount and the backup flags are kept to detect cloned keys.
+	WebAuthnCredential struct {
+		Id              int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+		UserId          int64      `json:"user_id" gorm:"column:user_id"`
+		CredentialId    []byte     `json:"-" gorm:"column:credential_id"`
+		PublicKey       []byte     `json:"-" gorm:"column:public_key"`
+		AttestationType string     `json:"attestation_type" gorm:"column:attestation_type"`
+		AAGUID          []byte     `json:"-" gorm:"column:aaguid"`
+		SignCount       uint32     `json:"-" gorm:"column:sign_count"`
+		Transports      string     `json:"transports" gorm:"column:transports"` // comma separated
+		BackupEligible  bool       `json:"backup_eligible" gorm:"column:backup_eligible"`
+		BackupState     bool       `json:"backup_state" gorm:"column:backup_state"`
+		Label           string     `json:"label" gorm:"column:label"`
+		LastUsedAt      *time.Time `json:"last_used_at,omitempty" gorm:"column:last_used_at"`
+		CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	}
+
+	// WebAuthnBeginOutput starts a ceremony. Options is passed to
+	// navigator.credentials.create or .get, and CeremonyId is sent back
+	// with the result.
+	WebAuthnBeginOutput struct {
+		CeremonyId string          `json:"ceremony_id"`
+		Options    json.RawMessage `json:"options" swaggertype:"object"`
+	}
+
+	WebAuthnRegisterReq struct {
+		CeremonyId string          `json:"ceremony_id" validate:"required"`
+		Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"` // PublicKeyCredential from navigator.credentials.create
+		Label      string          `json:"label,omitempty" validate:"omitempty,max=100"`
+	}
+
+	WebAuthnLoginReq struct {
+		CeremonyId  string          `json:"ceremony_id" validate:"required"`
+		Credential  json.RawMessage `json:"credential" validate:"required" swaggertype:"object"` // PublicKeyCredential from navigator.credentials.get
+		DeviceLabel string          `json:"device_label,omitempty" validate:"omitempty,max=100"`
+	}
+
+	WebAuthnCredentialMethodRepository interface {
+		WithContext(ctx context.Context) WebAuthnCredentialMethodRepository
+		FindByUserId(userId int64) (result []*WebAuthnCredential, err error)
+		Create(req *WebAuthnCredential) (result *WebAuthnCredential, err error)
+		RecordUse(id int64, signCount uint32, backupState bool) error
+	}
+
+	WebAuthnMethodService interface {
+		BeginRegistration(ctx context.Context, userId int64) (result *WebAuthnBeginOutput, err error)
+		FinishRegistration(ctx context.Context, userId int64, req *WebAuthnRegisterReq) (result *WebAuthnCredential, err error)
+		BeginLogin(ctx context.Context) (result *WebAuthnBeginOutput, err error)
+		FinishLogin(ctx context.Context, req *WebAuthnLoginReq) (result *User, err error)
+	}
+)
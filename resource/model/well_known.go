@@ -0,0 +1,11 @@
+package model
+
+import "github.com/petershaan12/go-auth-clean-arch/internal/token"
+
+type PasetoKeySet struct {
+	Keys []token.PublicKey `json:"keys"`
+}
+
+type JWKSet struct {
+	Keys []token.JWK `json:"keys"`
+}